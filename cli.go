@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+
+	needleman_wunsh "github.com/vsdbmv2/worker-go/needlemanWunsh"
+	. "github.com/vsdbmv2/worker-go/processor"
+	"github.com/vsdbmv2/worker-go/seqio"
+)
+
+// Exit codes of the offline commands
+const (
+	exitOK     = 0
+	exitFailed = 1 // a job failed, its Result carries the error
+	exitUsage  = 2
+)
+
+// command builds the works of an offline run from its arguments, files given
+// as - being read from stdin
+type command struct {
+	usage string
+	works func(flags *flag.FlagSet, args []string, stdin io.Reader) ([]Work, error)
+}
+
+// commandNames lists the commands in the order usage shows them
+var commandNames = []string{"align-global", "align-glocal", "align-local", "map-epitopes", "run"}
+
+var commands = map[string]command{
+	"align-global": {
+		usage: "align-global [flags] REFERENCE [QUERY]\n\nAligns every QUERY record globally against every REFERENCE record.",
+		works: globalWorks,
+	},
+	"align-glocal": {
+		usage: "align-glocal [flags] REFERENCE [QUERY]\n\nAligns every QUERY record as a whole against part of every REFERENCE record.",
+		works: glocalWorks,
+	},
+	"align-local": {
+		usage: "align-local [flags] REFERENCE [QUERY]\n\nAligns every QUERY record locally against every REFERENCE record.",
+		works: localWorks,
+	},
+	"map-epitopes": {
+		usage: "map-epitopes -epitopes EPITOPES [flags] [SEQUENCES]\n\nMaps the EPITOPES records on every SEQUENCES record.",
+		works: epitopeWorks,
+	},
+	"run": {
+		usage: "run [WORKS]\n\nRuns a JSON array of works, as sent by the server in a work event.",
+		works: jsonWorks,
+	},
+}
+
+// runCommand runs the jobs of an offline command, printing the Result the
+// server would get for each of them as a line of JSON on stdout. Files are
+// FASTA, FASTQ or GenBank, possibly gzipped, and stdin is read when the last
+// one is omitted or given as -.
+func runCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	switch args[0] {
+	case "-h", "-help", "--help", "help":
+		usage(stdout)
+		return exitOK
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: worker %s\n\n", cmd.usage)
+		flags.PrintDefaults()
+	}
+	works, err := cmd.works(flags, args[1:], stdin)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	status := exitOK
+	encoder := json.NewEncoder(stdout)
+	for _, work := range works {
+		result := Process(ctx, work)
+		if result.Error != nil {
+			status = exitFailed
+		}
+		if err := encoder.Encode(result); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailed
+		}
+	}
+	return status
+}
+
+// usage prints how to run the worker and its offline commands
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: worker [COMMAND [flags] [FILES]]")
+	fmt.Fprintln(w, "\nWithout a command, the worker serves the jobs of the server. The commands\nrun jobs offline, printing their results as lines of JSON:")
+	for _, name := range commandNames {
+		fmt.Fprintf(w, "\n  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(w, "\nRun worker COMMAND -h for the flags of a command.")
+}
+
+func globalWorks(flags *flag.FlagSet, args []string, stdin io.Reader) ([]Work, error) {
+	matrix := flags.String("matrix", "", "substitution matrix, e.g. BLOSUM62")
+	scoring := scoringFlags(flags)
+	banded := flags.Bool("band", false, "align within a band around the diagonal, the whole matrix when it does not hold the alignment")
+	var band needleman_wunsh.BandParams
+	flags.IntVar(&band.Width, "band-width", needleman_wunsh.DefaultBandWidth, "diagonals on each side of the main one with -band")
+	flags.IntVar(&band.XDrop, "x-drop", 0, "score below the best one at which rows of the band stop with -band, never when 0")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	works, err := pairWorks(GlobalMapping, flags.Args(), stdin)
+	for i := range works {
+		works[i].Matrix = *matrix
+		works[i].Scoring = scoring
+		if *banded {
+			works[i].Band = &band
+		}
+	}
+	return works, err
+}
+
+func glocalWorks(flags *flag.FlagSet, args []string, stdin io.Reader) ([]Work, error) {
+	matrix := flags.String("matrix", "", "substitution matrix, e.g. BLOSUM62")
+	scoring := scoringFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	works, err := pairWorks(GlocalMapping, flags.Args(), stdin)
+	for i := range works {
+		works[i].Matrix = *matrix
+		works[i].Scoring = scoring
+	}
+	return works, err
+}
+
+// scoringFlags defines the flags of a Needleman-Gotoh scoring scheme
+func scoringFlags(flags *flag.FlagSet) *needleman_wunsh.ScoringParams {
+	scoring := needleman_wunsh.DefaultScoringParams()
+	flags.IntVar(&scoring.Match, "match", scoring.Match, "match score")
+	flags.IntVar(&scoring.Mismatch, "mismatch", scoring.Mismatch, "mismatch score")
+	flags.IntVar(&scoring.GapOpen, "gap-open", scoring.GapOpen, "gap opening penalty")
+	flags.IntVar(&scoring.GapExtension, "gap-extension", scoring.GapExtension, "gap extension penalty")
+	return &scoring
+}
+
+func localWorks(flags *flag.FlagSet, args []string, stdin io.Reader) ([]Work, error) {
+	matrix := flags.String("matrix", "", "substitution matrix, e.g. BLOSUM62")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	works, err := pairWorks(LocalMapping, flags.Args(), stdin)
+	for i := range works {
+		works[i].Matrix = *matrix
+	}
+	return works, err
+}
+
+// pairWorks returns a work of workType for every pair of a reference and a
+// query record, identified by their IDs
+func pairWorks(workType WorkType, paths []string, stdin io.Reader) ([]Work, error) {
+	if len(paths) < 1 || len(paths) > 2 {
+		return nil, errors.New("expected a REFERENCE and an optional QUERY file")
+	}
+	if paths[0] == "-" && fileArg(paths, 1) == "-" {
+		return nil, errors.New("REFERENCE and QUERY cannot both be read from standard input")
+	}
+	references, err := readSequenceFile(paths[0], stdin)
+	if err != nil {
+		return nil, err
+	}
+	queries, err := readSequenceFile(fileArg(paths, 1), stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	var works []Work
+	for _, reference := range references {
+		for _, query := range queries {
+			works = append(works, Work{
+				Type:       workType,
+				ID1:        numericID(reference.ID),
+				Sequence1:  reference.Sequence,
+				Sequence2:  SequencePayloadOf(query.Sequence),
+				ID2:        numericID(query.ID),
+				Identifier: reference.ID + "/" + query.ID,
+			})
+		}
+	}
+	return works, nil
+}
+
+func epitopeWorks(flags *flag.FlagSet, args []string, stdin io.Reader) ([]Work, error) {
+	epitopesPath := flags.String("epitopes", "", "file of the epitopes, numeric IDs being kept as epitope IDs")
+	template := Work{Type: EpitopeMapping}
+	flags.IntVar(&template.MaxDistance, "max-distance", 0, "differences allowed, exact matches only when 0")
+	flags.StringVar(&template.DistanceMetric, "metric", "", `"hamming" (default) or "edit"`)
+	flags.IntVar(&template.GeneticCode, "genetic-code", 0, "NCBI genetic code translating the sequences in six frames, none when 0")
+	flags.StringVar(&template.Ambiguity, "ambiguity", "", `how ambiguity codes match: "exact" (default), "iupac" or "mismatch"`)
+	flags.StringVar(&template.Alphabet, "alphabet", "", `alphabet of the ambiguity codes: "protein" (default) or "nucleotide"`)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if *epitopesPath == "" || flags.NArg() > 1 {
+		return nil, errors.New("expected -epitopes and an optional SEQUENCES file")
+	}
+	if *epitopesPath == "-" && fileArg(flags.Args(), 0) == "-" {
+		return nil, errors.New("EPITOPES and SEQUENCES cannot both be read from standard input")
+	}
+
+	records, err := readSequenceFile(*epitopesPath, stdin)
+	if err != nil {
+		return nil, err
+	}
+	epitopes := RecordEpitopes(records)
+
+	sequences, err := readSequenceFile(fileArg(flags.Args(), 0), stdin)
+	if err != nil {
+		return nil, err
+	}
+	works := make([]Work, len(sequences))
+	for i, sequence := range sequences {
+		work := template
+		work.ID1 = numericID(sequence.ID)
+		work.Sequence1 = sequence.Sequence
+		work.Sequence2 = EpitopePayloadOf(epitopes)
+		work.Identifier = sequence.ID
+		works[i] = work
+	}
+	return works, nil
+}
+
+func jsonWorks(flags *flag.FlagSet, args []string, stdin io.Reader) ([]Work, error) {
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 1 {
+		return nil, errors.New("expected an optional WORKS file")
+	}
+
+	var works []Work
+	err := withInput(fileArg(flags.Args(), 0), stdin, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&works)
+	})
+	return works, err
+}
+
+func readSequenceFile(path string, stdin io.Reader) ([]seqio.Record, error) {
+	var records []seqio.Record
+	err := withInput(path, stdin, func(r io.Reader) error {
+		reader, err := seqio.NewReader(r)
+		if err != nil {
+			return err
+		}
+		records, err = seqio.ReadAll(reader)
+		return err
+	})
+	if err == nil && len(records) == 0 {
+		err = fmt.Errorf("%s: no sequence records", path)
+	}
+	return records, err
+}
+
+// withInput calls read with the file at path, or with stdin when path is -
+func withInput(path string, stdin io.Reader, read func(io.Reader) error) error {
+	if path == "-" {
+		return read(stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := read(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// fileArg returns the i-th path of args, - when it is missing
+func fileArg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return "-"
+}
+
+// numericID returns a record ID as a database ID, 0 when it is not a number
+func numericID(id string) int {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return 0
+	}
+	return n
+}
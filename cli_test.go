@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/vsdbmv2/worker-go/epitopeMap"
+	. "github.com/vsdbmv2/worker-go/processor"
+)
+
+// writeFile writes content to a file of a temporary directory, returning its
+// path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPairWorks(t *testing.T) {
+	references := writeFile(t, "references.fasta", ">1 reference\nACGTACGT\n>ref2\nTTGACA\n")
+	query := writeFile(t, "query.fasta", ">7\nACGTTCGT\n")
+	empty := writeFile(t, "empty.fasta", "")
+
+	tests := []struct {
+		name    string
+		paths   []string
+		stdin   string
+		want    []Work
+		wantErr bool
+	}{
+		{
+			name:  "files",
+			paths: []string{references, query},
+			want: []Work{
+				{Type: GlobalMapping, ID1: 1, Sequence1: "ACGTACGT", Sequence2: SequencePayloadOf("ACGTTCGT"), ID2: 7, Identifier: "1/7"},
+				{Type: GlobalMapping, Sequence1: "TTGACA", Sequence2: SequencePayloadOf("ACGTTCGT"), ID2: 7, Identifier: "ref2/7"},
+			},
+		},
+		{
+			name:  "query from stdin",
+			paths: []string{query},
+			stdin: ">q\nACGT\n",
+			want: []Work{
+				{Type: GlobalMapping, ID1: 7, Sequence1: "ACGTTCGT", Sequence2: SequencePayloadOf("ACGT"), Identifier: "7/q"},
+			},
+		},
+		{
+			name:  "reference from stdin",
+			paths: []string{"-", query},
+			stdin: ">r\nACGT\n",
+			want: []Work{
+				{Type: GlobalMapping, Sequence1: "ACGT", Sequence2: SequencePayloadOf("ACGTTCGT"), ID2: 7, Identifier: "r/7"},
+			},
+		},
+		{name: "both from stdin", paths: []string{"-"}, stdin: ">r\nACGT\n", wantErr: true},
+		{name: "both given as stdin", paths: []string{"-", "-"}, stdin: ">r\nACGT\n", wantErr: true},
+		{name: "no files", wantErr: true},
+		{name: "too many files", paths: []string{references, query, query}, wantErr: true},
+		{name: "missing file", paths: []string{filepath.Join(t.TempDir(), "missing.fasta"), query}, wantErr: true},
+		{name: "no records", paths: []string{references, empty}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := pairWorks(GlobalMapping, tt.paths, strings.NewReader(tt.stdin))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("pairWorks(%v) error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("pairWorks(%v) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEpitopeWorks(t *testing.T) {
+	epitopes := writeFile(t, "epitopes.fasta", ">12\nSIINFEKL\n>e2\nGILGFVFTL\n")
+	sequences := writeFile(t, "sequences.fasta", ">3\nMSIINFEKLG\n")
+	wantEpitopes := EpitopePayloadOf([]Epitope{{ID: 12, LinearSequence: "SIINFEKL"}, {LinearSequence: "GILGFVFTL"}})
+
+	tests := []struct {
+		name    string
+		args    []string
+		stdin   string
+		want    []Work
+		wantErr bool
+	}{
+		{
+			name: "files",
+			args: []string{"-epitopes", epitopes, "-max-distance", "1", "-metric", "edit", sequences},
+			want: []Work{{Type: EpitopeMapping, ID1: 3, Sequence1: "MSIINFEKLG", Sequence2: wantEpitopes,
+				Identifier: "3", MaxDistance: 1, DistanceMetric: "edit"}},
+		},
+		{
+			name:  "sequences from stdin",
+			args:  []string{"-epitopes", epitopes},
+			stdin: ">s\nGILGFVFTL\n",
+			want:  []Work{{Type: EpitopeMapping, Sequence1: "GILGFVFTL", Sequence2: wantEpitopes, Identifier: "s"}},
+		},
+		{name: "both from stdin", args: []string{"-epitopes", "-"}, stdin: ">12\nSIINFEKL\n", wantErr: true},
+		{name: "no epitopes", args: []string{sequences}, wantErr: true},
+		{name: "too many files", args: []string{"-epitopes", epitopes, sequences, sequences}, wantErr: true},
+		{name: "missing file", args: []string{"-epitopes", filepath.Join(t.TempDir(), "missing.fasta"), sequences}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		flags := flag.NewFlagSet("map-epitopes", flag.ContinueOnError)
+		got, err := epitopeWorks(flags, tt.args, strings.NewReader(tt.stdin))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("epitopeWorks(%v) error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("epitopeWorks(%v) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	reference := writeFile(t, "reference.fasta", ">1\nACGTACGTACGT\n")
+	query := writeFile(t, "query.fasta", ">2\nACGTACGTACGT\n")
+	works := writeFile(t, "works.json", `[{"type": "global-mapping", "identifier": "ok", "sequence1": "ACGT", "sequence2": "ACGT"},
+		{"type": "unknown", "identifier": "failed"}]`)
+
+	tests := []struct {
+		name        string
+		args        []string
+		stdin       string
+		want        int
+		wantResults []string // identifiers of the Results printed
+		wantStdout  string   // printed on stdout instead of Results
+		wantStderr  string
+	}{
+		{name: "help", args: []string{"-h"}, want: exitOK, wantStdout: "Usage: worker"},
+		{name: "long help", args: []string{"--help"}, want: exitOK, wantStdout: "map-epitopes -epitopes"},
+		{name: "command help", args: []string{"align-global", "-h"}, want: exitOK, wantStderr: "Usage: worker align-global"},
+		{name: "unknown command", args: []string{"align"}, want: exitUsage, wantStderr: `unknown command "align"`},
+		{name: "align", args: []string{"align-global", reference, query}, want: exitOK, wantResults: []string{"1/2"}},
+		{name: "align from stdin", args: []string{"align-local", reference}, stdin: ">3\nCGTACG\n", want: exitOK, wantResults: []string{"1/3"}},
+		{name: "both from stdin", args: []string{"align-global", "-"}, stdin: ">1\nACGT\n", want: exitUsage, wantStderr: "standard input"},
+		{name: "bad flag", args: []string{"align-global", "-band-width", "wide", reference}, want: exitUsage},
+		{name: "failed job", args: []string{"run", works}, want: exitFailed, wantResults: []string{"ok", "failed"}},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if got := runCommand(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr); got != tt.want {
+			t.Errorf("runCommand(%v) = %v, want %v, stderr %q", tt.name, got, tt.want, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), tt.wantStdout) || !strings.Contains(stderr.String(), tt.wantStderr) {
+			t.Errorf("runCommand(%v) printed %q and %q, want %q and %q", tt.name, stdout.String(), stderr.String(), tt.wantStdout, tt.wantStderr)
+		}
+		if tt.wantStdout != "" {
+			continue
+		}
+
+		var identifiers []string
+		decoder := json.NewDecoder(&stdout)
+		for {
+			var result Result
+			if err := decoder.Decode(&result); err != nil {
+				if !errors.Is(err, io.EOF) {
+					t.Errorf("runCommand(%v) printed a bad Result: %v", tt.name, err)
+				}
+				break
+			}
+			identifiers = append(identifiers, result.Identifier)
+		}
+		if !reflect.DeepEqual(identifiers, tt.wantResults) {
+			t.Errorf("runCommand(%v) printed Results %v, want %v", tt.name, identifiers, tt.wantResults)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Backoff bounds for redialing the server
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+	stableConnection  = time.Minute     // up this long, a connection resets the backoff
+	closeTimeout      = time.Second     // to write the close frame when leaving
+	writeTimeout      = 5 * time.Second // to write an event before dropping the connection
+)
+
+var errOffline = errors.New("not connected to the server")
+
+// outbound is a single {type, payload} message sent to the server
+type outbound struct {
+	Type    string      `json:"type"`
+	Payload interface{} `json:"payload"`
+}
+
+// inbound is a single {type, payload} message received from the server
+type inbound struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// connection keeps the worker attached to the server. Writes are serialized,
+// since a websocket supports only one concurrent writer, and events delivered
+// while offline are held until the next successful reconnect.
+type connection struct {
+	host string
+
+	// Backoff state of connect, kept across connections so that a server
+	// dropping them right away is not redialed in a tight loop
+	attempt  int
+	attached time.Time
+
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	pending [][]byte // encoded events, oldest first
+}
+
+func newConnection(host string) *connection {
+	return &connection{host: host}
+}
+
+// connect dials the server until it succeeds or ctx is done, waiting a
+// jittered exponential backoff between attempts, then resends every pending
+// event. A connection that dropped before staying up for stableConnection
+// counts as a failed attempt, so it is also redialed after a backoff. It must
+// not be called concurrently.
+func (c *connection) connect(ctx context.Context) (*websocket.Conn, error) {
+	if !c.attached.IsZero() {
+		if time.Since(c.attached) >= stableConnection {
+			c.attempt = 0
+		} else if err := c.wait(ctx); err != nil {
+			return nil, err
+		}
+		c.attached = time.Time{}
+	}
+
+	for {
+		ws, _, err := websocket.DefaultDialer.DialContext(ctx, c.host, nil)
+		if err == nil {
+			c.attached = time.Now()
+			c.attach(ws)
+			return ws, nil
+		}
+
+		connectionFailures.Inc()
+		log.Printf("WebSocket connection error: %v", err)
+		if err := c.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// connectNow is connect dialing right away, whatever the backoff built up
+// by earlier connections, for a worker shutting down that has no time to
+// wait for it.
+func (c *connection) connectNow(ctx context.Context) (*websocket.Conn, error) {
+	c.attempt = 0
+	c.attached = time.Time{}
+	return c.connect(ctx)
+}
+
+// wait sleeps the backoff of the next attempt, or until ctx is done
+func (c *connection) wait(ctx context.Context) error {
+	delay := backoff(c.attempt)
+	c.attempt++
+	log.Printf("Reconnecting in %v", delay)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// attach makes ws the active connection and flushes the pending events on it
+func (c *connection) attach(ws *websocket.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.conn = ws
+	if len(c.pending) > 0 {
+		log.Printf("Resending %d events held while offline", len(c.pending))
+	}
+	c.flush()
+}
+
+// flush writes the pending events on the active connection, oldest first,
+// keeping the ones left if a write fails. c.mu must be held.
+func (c *connection) flush() error {
+	for len(c.pending) > 0 {
+		if err := c.write(c.pending[0]); err != nil {
+			return err
+		}
+		c.pending = c.pending[1:]
+	}
+	return nil
+}
+
+// write sends an encoded event on the active connection, giving up after
+// writeTimeout so that a stalled server does not hold c.mu forever. A failed
+// write leaves the connection unusable, so it is closed, ending the read loop
+// for the worker to redial, and the worker is marked offline. c.mu must be
+// held.
+func (c *connection) write(data []byte) error {
+	err := c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err == nil {
+		err = c.conn.WriteMessage(websocket.TextMessage, data)
+	}
+	if err != nil {
+		log.Println("WebSocket write error:", err)
+		c.conn.Close()
+		c.conn = nil
+	}
+	return err
+}
+
+// detach closes ws and marks the worker as offline, unless a newer
+// connection has already replaced it.
+func (c *connection) detach(ws *websocket.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == ws {
+		c.conn = nil
+	}
+	ws.Close()
+}
+
+// leave sends the events still pending and a last event to the server,
+// then closes the connection with a normal closure. Pending events are lost
+// if the worker is offline.
+func (c *connection) leave(eventType string, payload interface{}) error {
+	data, err := encode(eventType, payload)
+	if err != nil {
+		log.Printf("Dropping %s event: %v", eventType, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		c.pending = append(c.pending, data)
+	}
+	if c.conn == nil {
+		if len(c.pending) > 0 {
+			log.Printf("Dropping %d events never delivered", len(c.pending))
+		}
+		return errOffline
+	}
+	ws := c.conn
+	defer ws.Close()
+
+	if err := c.flush(); err != nil {
+		log.Printf("Dropping %d events never delivered", len(c.pending))
+		return err
+	}
+	c.conn = nil
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "worker shutting down")
+	return ws.WriteControl(websocket.CloseMessage, message, time.Now().Add(closeTimeout))
+}
+
+// online reports whether the worker is connected to the server
+func (c *connection) online() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.conn != nil
+}
+
+// send writes an event to the server, failing if the worker is offline
+func (c *connection) send(eventType string, payload interface{}) error {
+	data, err := encode(eventType, payload)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return errOffline
+	}
+	return c.write(data)
+}
+
+// deliver writes an event to the server, holding it for resend after the
+// next reconnect if the worker is offline or the write fails. An event that
+// cannot be encoded is dropped, since resending it would fail forever.
+func (c *connection) deliver(eventType string, payload interface{}) {
+	data, err := encode(eventType, payload)
+	if err != nil {
+		log.Printf("Dropping %s event: %v", eventType, err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil && c.write(data) == nil {
+		return
+	}
+	c.pending = append(c.pending, data)
+}
+
+// encode marshals a {type, payload} message
+func encode(eventType string, payload interface{}) ([]byte, error) {
+	return json.Marshal(outbound{Type: eventType, Payload: payload})
+}
+
+// readEvents passes every event read on ws to handle, skipping malformed
+// messages, until reading fails
+func readEvents(ws *websocket.Conn, handle func(inbound)) error {
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		var event inbound
+		if err := json.Unmarshal(message, &event); err != nil {
+			log.Println("JSON parse error:", err)
+			continue
+		}
+		handle(event)
+	}
+}
+
+// backoff returns the delay before the given redial attempt: an exponentially
+// growing window, capped at maxReconnectDelay, with the upper half jittered so
+// that a fleet of workers does not reconnect in lockstep.
+func backoff(attempt int) time.Duration {
+	delay := maxReconnectDelay
+	if attempt < 16 {
+		delay = min(minReconnectDelay<<attempt, maxReconnectDelay)
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// server is a websocket test server passing every connection it accepts to
+// serve, which closes it on return
+func server(t *testing.T, serve func(ws *websocket.Conn)) string {
+	t.Helper()
+	var upgrader websocket.Upgrader
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer ws.Close()
+		serve(ws)
+	}))
+	t.Cleanup(s.Close)
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+// readTypes reads n events on ws, returning their types
+func readTypes(t *testing.T, ws *websocket.Conn, n int) []string {
+	t.Helper()
+	var types []string
+	for len(types) < n {
+		var event inbound
+		if err := ws.ReadJSON(&event); err != nil {
+			t.Errorf("reading event %d: %v", len(types), err)
+			break
+		}
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestConnectionResend(t *testing.T) {
+	received := make(chan []string, 1)
+	c := newConnection(server(t, func(ws *websocket.Conn) {
+		received <- readTypes(t, ws, 3)
+	}))
+
+	// Held while offline, except for the event that cannot be encoded
+	c.deliver("first", nil)
+	c.deliver("broken", json.RawMessage("{oops"))
+	c.deliver("second", nil)
+	if len(c.pending) != 2 {
+		t.Fatalf("%d events pending, want 2", len(c.pending))
+	}
+
+	ws, err := c.connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	c.deliver("third", nil)
+
+	want := []string{"first", "second", "third"}
+	if got := <-received; !reflect.DeepEqual(got, want) {
+		t.Errorf("server received %v, want %v", got, want)
+	}
+	if len(c.pending) != 0 {
+		t.Errorf("%d events still pending", len(c.pending))
+	}
+}
+
+func TestConnectionBackoff(t *testing.T) {
+	// The server drops every connection right away
+	host := server(t, func(ws *websocket.Conn) {})
+	c := newConnection(host)
+
+	ws, err := c.connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := readEvents(ws, func(inbound) {}); err == nil {
+		t.Fatal("readEvents succeeded on a dropped connection")
+	}
+	c.detach(ws)
+
+	start := time.Now()
+	ws, err = c.connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.Close()
+	if elapsed := time.Since(start); elapsed < minReconnectDelay/2 {
+		t.Errorf("redialed after %v, want a backoff of at least %v", elapsed, minReconnectDelay/2)
+	}
+	if c.attempt != 1 {
+		t.Errorf("attempt = %d, want 1", c.attempt)
+	}
+
+	// A connection that stayed up long enough resets the backoff
+	c.attempt = 10
+	c.attached = time.Now().Add(-stableConnection)
+	start = time.Now()
+	ws, err = c.connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.Close()
+	if elapsed := time.Since(start); elapsed >= minReconnectDelay/2 {
+		t.Errorf("redialed after %v, want no backoff", elapsed)
+	}
+	if c.attempt != 0 {
+		t.Errorf("attempt = %d, want 0", c.attempt)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import . "github.com/vsdbmv2/worker-go/processor"
+
+// dispatchResults streams every finished Result back to the server as its own
+// work-complete event, or work-failed when the job reported an error, without
+// waiting for incoming traffic. Results finished while the worker is offline
+// are resent once it reconnects.
+func dispatchResults(c *connection, results <-chan Result) {
+	for result := range results {
+		if result.Error != nil {
+			c.deliver("work-failed", result)
+			continue
+		}
+		c.deliver("work-complete", result)
+	}
+}
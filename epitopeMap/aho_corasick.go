@@ -0,0 +1,224 @@
+package epitope_map
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+)
+
+// Matcher finds a set of epitopes in a single pass over a sequence, with an
+// Aho-Corasick automaton. It is safe for concurrent use.
+type Matcher struct {
+	epitopes []Epitope
+
+	alphabet [256]int // residue index in the automaton, -1 when no epitope has it
+	size     int      // number of residues in the alphabet
+	delta    []int32  // next state for each state and residue
+	output   [][]int  // epitopes ending on each state
+	dict     []int32  // next state on the failure chain with an output, 0 for none
+	empty    []int    // epitopes with an empty sequence, found everywhere
+}
+
+// NewMatcher builds the automaton of epitopes
+func NewMatcher(epitopes []Epitope) *Matcher {
+	m := &Matcher{epitopes: epitopes}
+	for i := range m.alphabet {
+		m.alphabet[i] = -1
+	}
+	for _, epitope := range epitopes {
+		for i := 0; i < len(epitope.LinearSequence); i++ {
+			if c := epitope.LinearSequence[i]; m.alphabet[c] < 0 {
+				m.alphabet[c] = m.size
+				m.size++
+			}
+		}
+	}
+
+	// Trie of the epitopes, missing transitions set to -1
+	m.addState()
+	for index, epitope := range epitopes {
+		if epitope.LinearSequence == "" {
+			m.empty = append(m.empty, index)
+			continue
+		}
+		state := int32(0)
+		for i := 0; i < len(epitope.LinearSequence); i++ {
+			transition := int(state)*m.size + m.alphabet[epitope.LinearSequence[i]]
+			if m.delta[transition] < 0 {
+				next := m.addState() // grows delta, so assigned apart
+				m.delta[transition] = next
+			}
+			state = m.delta[transition]
+		}
+		m.output[state] = append(m.output[state], index)
+	}
+
+	// Breadth first, complete the transitions of every state with those of
+	// its failure state, which is always closer to the root
+	fail := make([]int32, len(m.output))
+	queue := make([]int32, 0, len(m.output))
+	for c := 0; c < m.size; c++ {
+		if next := m.delta[c]; next < 0 {
+			m.delta[c] = 0
+		} else {
+			queue = append(queue, next)
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		if failure := fail[state]; len(m.output[failure]) > 0 {
+			m.dict[state] = failure
+		} else {
+			m.dict[state] = m.dict[failure]
+		}
+
+		for c := 0; c < m.size; c++ {
+			next := &m.delta[int(state)*m.size+c]
+			fallback := m.delta[int(fail[state])*m.size+c]
+			if *next < 0 {
+				*next = fallback
+			} else {
+				fail[*next] = fallback
+				queue = append(queue, *next)
+			}
+		}
+	}
+	return m
+}
+
+func (m *Matcher) addState() int32 {
+	for c := 0; c < m.size; c++ {
+		m.delta = append(m.delta, -1)
+	}
+	m.output = append(m.output, nil)
+	m.dict = append(m.dict, 0)
+	return int32(len(m.output) - 1)
+}
+
+// matchCheckInterval is the number of residues matched between checks of
+// the context
+const matchCheckInterval = 1 << 16
+
+// Match returns the same maps as MapEpitopes for the epitopes of the matcher
+func (m *Matcher) Match(sequence string) []EpitopeMap {
+	maps, _ := m.MatchContext(context.Background(), sequence)
+	return maps
+}
+
+// MatchContext is Match, giving up with the error of ctx once it is done
+func (m *Matcher) MatchContext(ctx context.Context, sequence string) ([]EpitopeMap, error) {
+	positions := make([][]int, len(m.epitopes))
+	for _, index := range m.empty {
+		for i := 0; i <= len(sequence); i++ {
+			positions[index] = append(positions[index], i)
+		}
+	}
+
+	state := int32(0)
+	for i := 0; i < len(sequence); i++ {
+		if i%matchCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		c := m.alphabet[sequence[i]]
+		if c < 0 {
+			state = 0
+			continue
+		}
+		state = m.delta[int(state)*m.size+c]
+
+		found := state
+		if len(m.output[found]) == 0 {
+			found = m.dict[found]
+		}
+		for ; found > 0; found = m.dict[found] {
+			for _, index := range m.output[found] {
+				positions[index] = append(positions[index], i+1-len(m.epitopes[index].LinearSequence))
+			}
+		}
+	}
+
+	// Ordered like MapEpitopes, by epitope then position
+	var maps []EpitopeMap
+	for index, starts := range positions {
+		epitope := m.epitopes[index]
+		for _, start := range starts {
+			maps = append(maps, EpitopeMap{
+				LinearSequence: epitope.LinearSequence,
+				InitPos:        start,
+				IDEpitope:      epitope.ID,
+			})
+		}
+	}
+	return maps, nil
+}
+
+// matcherCacheSize is the number of epitope sets whose automaton is kept
+const matcherCacheSize = 32
+
+var matchers = struct {
+	sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	recent  *list.List // of *cachedMatcher, most recently used first
+}{
+	entries: make(map[[sha256.Size]byte]*list.Element),
+	recent:  list.New(),
+}
+
+type cachedMatcher struct {
+	key     [sha256.Size]byte
+	matcher *Matcher
+}
+
+// MatcherFor returns the matcher of epitopes, reusing the automaton built for
+// the same epitope set, in the same order, by a previous call
+func MatcherFor(epitopes []Epitope) *Matcher {
+	key := epitopeSetKey(epitopes)
+
+	matchers.Lock()
+	if element, ok := matchers.entries[key]; ok {
+		matchers.recent.MoveToFront(element)
+		matchers.Unlock()
+		return element.Value.(*cachedMatcher).matcher
+	}
+	matchers.Unlock()
+
+	// Built outside the lock, a concurrent build of the same set only costs
+	// the duplicate work
+	matcher := NewMatcher(epitopes)
+
+	matchers.Lock()
+	defer matchers.Unlock()
+	if element, ok := matchers.entries[key]; ok {
+		return element.Value.(*cachedMatcher).matcher
+	}
+	matchers.entries[key] = matchers.recent.PushFront(&cachedMatcher{key: key, matcher: matcher})
+	if matchers.recent.Len() > matcherCacheSize {
+		oldest := matchers.recent.Remove(matchers.recent.Back()).(*cachedMatcher)
+		delete(matchers.entries, oldest.key)
+	}
+	return matcher
+}
+
+// epitopeSetKey hashes the IDs and sequences of epitopes, which are all that
+// the maps depend on
+func epitopeSetKey(epitopes []Epitope) [sha256.Size]byte {
+	hash := sha256.New()
+	var buffer [2 * binary.MaxVarintLen64]byte
+	for _, epitope := range epitopes {
+		n := binary.PutVarint(buffer[:], int64(epitope.ID))
+		n += binary.PutUvarint(buffer[n:], uint64(len(epitope.LinearSequence)))
+		hash.Write(buffer[:n])
+		hash.Write([]byte(epitope.LinearSequence))
+	}
+
+	var key [sha256.Size]byte
+	hash.Sum(key[:0])
+	return key
+}
@@ -0,0 +1,99 @@
+package epitope_map
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestMatcherMatch(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	random := func(alphabet string, length int) string {
+		sequence := make([]byte, length)
+		for i := range sequence {
+			sequence[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		return string(sequence)
+	}
+
+	tests := []struct {
+		sequence string
+		epitopes []Epitope
+	}{
+		{
+			sequence: "ATGGCTAGCT",
+			epitopes: []Epitope{{ID: 1, LinearSequence: "GCT"}, {ID: 2, LinearSequence: "AGC"}},
+		},
+		{
+			// Epitopes inside other epitopes, repeated, or never found
+			sequence: "AAAAGAAAAGA",
+			epitopes: []Epitope{
+				{LinearSequence: "AAAA"}, {LinearSequence: "AA"}, {LinearSequence: "AAGA"},
+				{ID: 3, LinearSequence: "AA"}, {LinearSequence: "GG"}, {LinearSequence: "A"},
+			},
+		},
+		{
+			sequence: "MKT-LLILAV",
+			epitopes: []Epitope{{LinearSequence: ""}, {LinearSequence: "LIL"}, {LinearSequence: "KTL"}},
+		},
+		{
+			sequence: "ACGT",
+			epitopes: nil,
+		},
+	}
+	for k := 0; k < 20; k++ {
+		sequence := random("ACDE", 200+r.Intn(200))
+		var epitopes []Epitope
+		for i := 0; i < 50; i++ {
+			epitope := random("ACDEF", 1+r.Intn(6))
+			if r.Intn(2) == 0 {
+				start := r.Intn(len(sequence) - 10)
+				epitope = sequence[start : start+1+r.Intn(10)]
+			}
+			epitopes = append(epitopes, Epitope{ID: i, LinearSequence: epitope})
+		}
+		tests = append(tests, struct {
+			sequence string
+			epitopes []Epitope
+		}{sequence, epitopes})
+	}
+
+	for _, tt := range tests {
+		want := MapEpitopes(tt.sequence, tt.epitopes)
+		got := NewMatcher(tt.epitopes).Match(tt.sequence)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Match(%v, %v) = %v, want %v", tt.sequence, tt.epitopes, got, want)
+		}
+	}
+}
+
+func TestMatcherFor(t *testing.T) {
+	epitopes := []Epitope{{ID: 1, LinearSequence: "GCT"}, {ID: 2, LinearSequence: "AGC"}}
+	matcher := MatcherFor(epitopes)
+
+	same := []Epitope{{ID: 1, LinearSequence: "GCT"}, {ID: 2, LinearSequence: "AGC", Metadata: []byte(`{}`)}}
+	if MatcherFor(same) != matcher {
+		t.Errorf("MatcherFor(%v) built a new matcher for the same epitope set", same)
+	}
+
+	otherIDs := []Epitope{{ID: 1, LinearSequence: "GCT"}, {ID: 3, LinearSequence: "AGC"}}
+	if MatcherFor(otherIDs) == matcher {
+		t.Errorf("MatcherFor(%v) reused the matcher of another epitope set", otherIDs)
+	}
+	reordered := []Epitope{{ID: 2, LinearSequence: "AGC"}, {ID: 1, LinearSequence: "GCT"}}
+	if MatcherFor(reordered) == matcher {
+		t.Errorf("MatcherFor(%v) reused the matcher of another epitope order", reordered)
+	}
+}
+
+func TestMatcherMatchCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewMatcher([]Epitope{{LinearSequence: "GCT"}}).MatchContext(ctx, "ATGGCTAGCT")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("MatchContext() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,95 @@
+package epitope_map
+
+import "fmt"
+
+// Alphabet decides which residues the ambiguity codes stand for
+type Alphabet string
+
+const (
+	Nucleotide Alphabet = "nucleotide" // IUPAC nucleotide codes, e.g. R for A or G
+	Protein    Alphabet = "protein"    // IUPAC amino acid codes: B, Z, J and X
+)
+
+// AmbiguityMode is how ambiguity codes match
+type AmbiguityMode string
+
+const (
+	AmbiguityExact    AmbiguityMode = "exact"    // codes only match the same code, like any residue
+	AmbiguityIUPAC    AmbiguityMode = "iupac"    // codes match the residues and codes they share a residue with
+	AmbiguityMismatch AmbiguityMode = "mismatch" // codes never match, not even the same code
+)
+
+// Residues each code stands for, by alphabet
+var ambiguityCodes = map[Alphabet]map[byte]string{
+	Nucleotide: {
+		'A': "A", 'C': "C", 'G': "G", 'T': "T", 'U': "T",
+		'R': "AG", 'Y': "CT", 'S': "CG", 'W': "AT", 'K': "GT", 'M': "AC",
+		'B': "CGT", 'D': "AGT", 'H': "ACT", 'V': "ACG", 'N': "ACGT",
+	},
+	Protein: {
+		'B': "DN", 'Z': "EQ", 'J': "IL", 'X': "ACDEFGHIKLMNOPQRSTUVWY",
+	},
+}
+
+// MatchRules tells which sequence residues match which epitope residues.
+// Codes are uppercase, lowercase letters only match themselves.
+type MatchRules struct {
+	matches [256][256]bool
+}
+
+// exactRules matches identical residues only
+var exactRules = func() *MatchRules {
+	rules := &MatchRules{}
+	for a := range 256 {
+		for b := range 256 {
+			rules.matches[a][b] = a == b
+		}
+	}
+	return rules
+}()
+
+// NewMatchRules returns the rules of the ambiguity codes of alphabet
+func NewMatchRules(alphabet Alphabet, mode AmbiguityMode) (*MatchRules, error) {
+	codes, ok := ambiguityCodes[alphabet]
+	if !ok {
+		return nil, fmt.Errorf("unknown alphabet %q", alphabet)
+	}
+
+	// Residues as bit sets, each residue letter being its own set unless it
+	// is a code of the alphabet
+	var sets [256]uint32
+	for r := byte('A'); r <= 'Z'; r++ {
+		sets[r] = 1 << (r - 'A')
+	}
+	ambiguous := make(map[byte]bool)
+	for code, residues := range codes {
+		sets[code] = 0
+		for i := 0; i < len(residues); i++ {
+			sets[code] |= 1 << (residues[i] - 'A')
+		}
+		ambiguous[code] = len(residues) > 1
+	}
+
+	rules := &MatchRules{}
+	for a := range 256 {
+		for b := range 256 {
+			x, y := byte(a), byte(b)
+			switch mode {
+			case AmbiguityExact, "":
+				rules.matches[a][b] = x == y
+			case AmbiguityIUPAC:
+				rules.matches[a][b] = x == y || sets[x]&sets[y] != 0
+			case AmbiguityMismatch:
+				rules.matches[a][b] = x == y && !ambiguous[x]
+			default:
+				return nil, fmt.Errorf("unknown ambiguity mode %q", mode)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// Match reports whether a residue of the sequence matches one of the epitope
+func (r *MatchRules) Match(sequenceResidue, epitopeResidue byte) bool {
+	return r.matches[sequenceResidue][epitopeResidue]
+}
@@ -0,0 +1,69 @@
+package epitope_map
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestNewMatchRules(t *testing.T) {
+	tests := []struct {
+		alphabet Alphabet
+		mode     AmbiguityMode
+		pairs    string // sequence and epitope residues, two by two
+		want     []bool
+	}{
+		{alphabet: Nucleotide, mode: AmbiguityExact, pairs: "AANNNARA", want: []bool{true, true, false, false}},
+		{alphabet: Nucleotide, mode: AmbiguityIUPAC, pairs: "RARGRCNTYSUTaA", want: []bool{true, true, false, true, true, true, false}},
+		{alphabet: Nucleotide, mode: AmbiguityMismatch, pairs: "AANNNARA", want: []bool{true, false, false, false}},
+		{alphabet: Protein, mode: AmbiguityIUPAC, pairs: "BDBNBEXWRARRJL", want: []bool{true, true, false, true, false, true, true}},
+		{alphabet: Protein, mode: AmbiguityMismatch, pairs: "XXRRZQ", want: []bool{false, true, false}},
+	}
+
+	for _, tt := range tests {
+		rules, err := NewMatchRules(tt.alphabet, tt.mode)
+		if err != nil {
+			t.Errorf("NewMatchRules(%v, %v) unexpected error %v", tt.alphabet, tt.mode, err)
+			continue
+		}
+		for i, want := range tt.want {
+			a, b := tt.pairs[2*i], tt.pairs[2*i+1]
+			if got := rules.Match(a, b); got != want {
+				t.Errorf("NewMatchRules(%v, %v).Match(%c, %c) = %v, want %v", tt.alphabet, tt.mode, a, b, got, want)
+			}
+		}
+	}
+
+	if _, err := NewMatchRules("rna", AmbiguityIUPAC); err == nil {
+		t.Errorf("NewMatchRules(rna) error = nil, want unknown alphabet")
+	}
+	if _, err := NewMatchRules(Protein, "fuzzy"); err == nil {
+		t.Errorf("NewMatchRules(fuzzy) error = nil, want unknown ambiguity mode")
+	}
+}
+
+func TestMapEpitopesWithRules(t *testing.T) {
+	rules, err := NewMatchRules(Protein, AmbiguityIUPAC)
+	if err != nil {
+		t.Fatalf("NewMatchRules() unexpected error %v", err)
+	}
+
+	// X in the sequence and B in the epitope match, overlapping matches too
+	sequence := "MSIIXFEKLSIINFEKLNFEKL"
+	epitopes := []Epitope{{ID: 1, LinearSequence: "SIIBFEKL"}, {ID: 2, LinearSequence: "NFEKL"}}
+	want := []EpitopeMap{
+		{LinearSequence: "SIIBFEKL", InitPos: 1, IDEpitope: 1},
+		{LinearSequence: "SIIBFEKL", InitPos: 9, IDEpitope: 1},
+		{LinearSequence: "NFEKL", InitPos: 4, IDEpitope: 2},
+		{LinearSequence: "NFEKL", InitPos: 12, IDEpitope: 2},
+		{LinearSequence: "NFEKL", InitPos: 17, IDEpitope: 2},
+	}
+
+	got, err := MapEpitopesWithRules(context.Background(), sequence, epitopes, 0, HammingDistance, rules)
+	if err != nil {
+		t.Fatalf("MapEpitopesWithRules() unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MapEpitopesWithRules(%v, %v) = %+v, want %+v", sequence, epitopes, got, want)
+	}
+}
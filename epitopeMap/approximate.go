@@ -0,0 +1,206 @@
+package epitope_map
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// DistanceMetric is how approximate matches are compared to their epitope
+type DistanceMetric string
+
+const (
+	HammingDistance DistanceMetric = "hamming" // substitutions only
+	EditDistance    DistanceMetric = "edit"    // substitutions, insertions and deletions
+)
+
+// Validate reports whether the metric is a known one, none meaning Hamming
+// distance
+func (m DistanceMetric) Validate() error {
+	switch m {
+	case HammingDistance, EditDistance, "":
+		return nil
+	}
+	return fmt.Errorf("unknown distance metric %q", m)
+}
+
+// Mutation is a difference between an epitope and where it was found
+type Mutation struct {
+	Position        int    `json:"position"`                   // 0-based, in the epitope; insertions come before it
+	EpitopeResidue  string `json:"epitope_residue,omitempty"`  // empty for an insertion
+	SequenceResidue string `json:"sequence_residue,omitempty"` // empty for a deletion
+}
+
+// MapEpitopesApprox maps epitopes like MapEpitopes, also reporting the places
+// where they are found with up to maxDistance differences. Each EpitopeMap
+// carries its distance and mutations. Edit distance matches are reported
+// once for each place where the distance reaches a local minimum. It gives up
+// with the error of ctx once it is done.
+func MapEpitopesApprox(ctx context.Context, sequence string, epitopes []Epitope, maxDistance int, metric DistanceMetric) ([]EpitopeMap, error) {
+	return MapEpitopesWithRules(ctx, sequence, epitopes, maxDistance, metric, exactRules)
+}
+
+// MapEpitopesWithRules maps epitopes like MapEpitopesApprox, residues
+// matching according to rules
+func MapEpitopesWithRules(ctx context.Context, sequence string, epitopes []Epitope, maxDistance int, metric DistanceMetric, rules *MatchRules) ([]EpitopeMap, error) {
+	if maxDistance < 0 {
+		return nil, fmt.Errorf("negative maximum distance %d", maxDistance)
+	}
+	if err := metric.Validate(); err != nil {
+		return nil, err
+	}
+
+	var maps []EpitopeMap
+	for _, epitope := range epitopes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if metric == EditDistance {
+			maps = append(maps, editMatches(sequence, epitope, maxDistance, rules)...)
+		} else {
+			maps = append(maps, hammingMatches(sequence, epitope, maxDistance, rules)...)
+		}
+	}
+	return maps, nil
+}
+
+func hammingMatches(sequence string, epitope Epitope, maxDistance int, rules *MatchRules) []EpitopeMap {
+	var maps []EpitopeMap
+	epitopeLen := len(epitope.LinearSequence)
+
+	for i := 0; i <= len(sequence)-epitopeLen; i++ {
+		distance := 0
+		for k := 0; k < epitopeLen && distance <= maxDistance; k++ {
+			if !rules.Match(sequence[i+k], epitope.LinearSequence[k]) {
+				distance++
+			}
+		}
+		if distance > maxDistance {
+			continue
+		}
+
+		var mutations []Mutation
+		for k := 0; k < epitopeLen; k++ {
+			if !rules.Match(sequence[i+k], epitope.LinearSequence[k]) {
+				mutations = append(mutations, Mutation{
+					Position:        k,
+					EpitopeResidue:  epitope.LinearSequence[k : k+1],
+					SequenceResidue: sequence[i+k : i+k+1],
+				})
+			}
+		}
+		maps = append(maps, EpitopeMap{
+			LinearSequence: epitope.LinearSequence,
+			InitPos:        i,
+			IDEpitope:      epitope.ID,
+			Distance:       distance,
+			Mutations:      mutations,
+		})
+	}
+	return maps
+}
+
+// editMatches scans the sequence once, keeping for every residue the smallest
+// edit distance of the epitope to a substring ending there, and traces back
+// the ends where it reaches a local minimum within maxDistance.
+func editMatches(sequence string, epitope Epitope, maxDistance int, rules *MatchRules) []EpitopeMap {
+	var maps []EpitopeMap
+	epitopeLen := len(epitope.LinearSequence)
+
+	distances := make([]int, epitopeLen+1)
+	for i := range distances {
+		distances[i] = i
+	}
+
+	candidate := -1 // end of the last local minimum not reported yet
+	previous := epitopeLen
+	for j := 1; j <= len(sequence); j++ {
+		diagonal := distances[0]
+		for i := 1; i <= epitopeLen; i++ {
+			cost := 1
+			if rules.Match(sequence[j-1], epitope.LinearSequence[i-1]) {
+				cost = 0
+			}
+			distance := min(diagonal+cost, distances[i]+1, distances[i-1]+1)
+			diagonal, distances[i] = distances[i], distance
+		}
+
+		distance := distances[epitopeLen]
+		if distance < previous && distance <= maxDistance {
+			candidate = j
+		} else if distance > previous && candidate >= 0 {
+			maps = append(maps, editMatch(sequence, epitope, candidate, maxDistance, rules))
+			candidate = -1
+		}
+		previous = distance
+	}
+	if candidate >= 0 {
+		maps = append(maps, editMatch(sequence, epitope, candidate, maxDistance, rules))
+	}
+
+	sort.SliceStable(maps, func(a, b int) bool { return maps[a].InitPos < maps[b].InitPos })
+	return maps
+}
+
+// editMatch aligns the epitope to the best substring ending at end, which
+// starts at most maxDistance residues before the epitope length.
+func editMatch(sequence string, epitope Epitope, end, maxDistance int, rules *MatchRules) EpitopeMap {
+	start := max(0, end-len(epitope.LinearSequence)-maxDistance)
+	window := sequence[start:end]
+	rows, cols := len(epitope.LinearSequence)+1, len(window)+1
+
+	distances := make([]int, rows*cols)
+	for i := 1; i < rows; i++ {
+		distances[i*cols] = i
+		for j := 1; j < cols; j++ {
+			cost := 1
+			if rules.Match(window[j-1], epitope.LinearSequence[i-1]) {
+				cost = 0
+			}
+			distances[i*cols+j] = min(distances[(i-1)*cols+j-1]+cost,
+				distances[(i-1)*cols+j]+1, distances[i*cols+j-1]+1)
+		}
+	}
+
+	// Trace back preferring substitutions, then deletions, then insertions
+	var mutations []Mutation
+	i, j := rows-1, cols-1
+	for i > 0 {
+		distance := distances[i*cols+j]
+		switch {
+		case j > 0 && rules.Match(window[j-1], epitope.LinearSequence[i-1]) && distances[(i-1)*cols+j-1] == distance:
+			i, j = i-1, j-1
+		case j > 0 && distances[(i-1)*cols+j-1]+1 == distance:
+			mutations = append(mutations, Mutation{
+				Position:        i - 1,
+				EpitopeResidue:  epitope.LinearSequence[i-1 : i],
+				SequenceResidue: window[j-1 : j],
+			})
+			i, j = i-1, j-1
+		case distances[(i-1)*cols+j]+1 == distance:
+			mutations = append(mutations, Mutation{
+				Position:       i - 1,
+				EpitopeResidue: epitope.LinearSequence[i-1 : i],
+			})
+			i--
+		default:
+			mutations = append(mutations, Mutation{
+				Position:        i,
+				SequenceResidue: window[j-1 : j],
+			})
+			j--
+		}
+	}
+	for a, b := 0, len(mutations)-1; a < b; a, b = a+1, b-1 {
+		mutations[a], mutations[b] = mutations[b], mutations[a]
+	}
+
+	return EpitopeMap{
+		LinearSequence: epitope.LinearSequence,
+		InitPos:        start + j,
+		IDEpitope:      epitope.ID,
+		Distance:       distances[len(distances)-1],
+		Mutations:      mutations,
+	}
+}
@@ -0,0 +1,119 @@
+package epitope_map
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMapEpitopesApprox(t *testing.T) {
+	tests := []struct {
+		sequence    string
+		epitopes    []Epitope
+		maxDistance int
+		metric      DistanceMetric
+		want        []EpitopeMap
+		wantErr     bool
+	}{
+		{
+			sequence:    "SIINFEKLSIIDFEKL",
+			epitopes:    []Epitope{{ID: 7, LinearSequence: "SIINFEKL"}},
+			maxDistance: 1,
+			metric:      HammingDistance,
+			want: []EpitopeMap{
+				{LinearSequence: "SIINFEKL", InitPos: 0, IDEpitope: 7},
+				{LinearSequence: "SIINFEKL", InitPos: 8, IDEpitope: 7, Distance: 1, Mutations: []Mutation{
+					{Position: 3, EpitopeResidue: "N", SequenceResidue: "D"},
+				}},
+			},
+		},
+		{
+			sequence:    "GLCTLVAML",
+			epitopes:    []Epitope{{LinearSequence: "GLCTLVAMLA"}, {LinearSequence: "GICTLVAML"}},
+			maxDistance: 1,
+			want: []EpitopeMap{
+				{LinearSequence: "GICTLVAML", InitPos: 0, Distance: 1, Mutations: []Mutation{
+					{Position: 1, EpitopeResidue: "I", SequenceResidue: "L"},
+				}},
+			},
+		},
+		{
+			sequence:    "MMMNLVPMVATVMMM",
+			epitopes:    []Epitope{{LinearSequence: "NLVPMVATV"}},
+			maxDistance: 0,
+			metric:      EditDistance,
+			want: []EpitopeMap{
+				{LinearSequence: "NLVPMVATV", InitPos: 3},
+			},
+		},
+		{
+			// Deletion and insertion of a residue of the epitope
+			sequence:    "MMMNLVPVATVMMMNLVPQMVATVMMM",
+			epitopes:    []Epitope{{ID: 2, LinearSequence: "NLVPMVATV"}},
+			maxDistance: 1,
+			metric:      EditDistance,
+			want: []EpitopeMap{
+				{LinearSequence: "NLVPMVATV", InitPos: 3, IDEpitope: 2, Distance: 1, Mutations: []Mutation{
+					{Position: 4, EpitopeResidue: "M"},
+				}},
+				{LinearSequence: "NLVPMVATV", InitPos: 14, IDEpitope: 2, Distance: 1, Mutations: []Mutation{
+					{Position: 4, SequenceResidue: "Q"},
+				}},
+			},
+		},
+		{
+			// Tandem copies are reported separately
+			sequence:    "AGCTGCTA",
+			epitopes:    []Epitope{{LinearSequence: "GCT"}},
+			maxDistance: 1,
+			metric:      EditDistance,
+			want: []EpitopeMap{
+				{LinearSequence: "GCT", InitPos: 1},
+				{LinearSequence: "GCT", InitPos: 4},
+			},
+		},
+		{
+			sequence:    "GCT",
+			epitopes:    []Epitope{{LinearSequence: "GCT"}},
+			maxDistance: -1,
+			wantErr:     true,
+		},
+		{
+			sequence:    "GCT",
+			epitopes:    []Epitope{{LinearSequence: "GCT"}},
+			maxDistance: 1,
+			metric:      "levenshtein",
+			wantErr:     true,
+		},
+		{
+			sequence:    "GCT",
+			maxDistance: 1,
+			metric:      "levenshtein",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := MapEpitopesApprox(context.Background(), tt.sequence, tt.epitopes, tt.maxDistance, tt.metric)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("MapEpitopesApprox(%v, %v, %v, %v) error = %v, wantErr %v",
+				tt.sequence, tt.epitopes, tt.maxDistance, tt.metric, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MapEpitopesApprox(%v, %v, %v, %v) = %+v, want %+v",
+				tt.sequence, tt.epitopes, tt.maxDistance, tt.metric, got, tt.want)
+		}
+	}
+}
+
+func TestMapEpitopesApproxCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := MapEpitopesApprox(ctx, "ATGGCTAGCT", []Epitope{{LinearSequence: "GCT"}}, 1, EditDistance)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("MapEpitopesApprox() error = %v, want %v", err, context.Canceled)
+	}
+}
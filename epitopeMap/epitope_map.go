@@ -1,31 +1,96 @@
 package epitope_map
 
+import (
+    "context"
+    "encoding/json"
+    "errors"
+)
+
 type EpitopeMap struct {
     LinearSequence string `json:"linearSequence"`
     InitPos       int    `json:"init_pos"`
+    IDEpitope     int    `json:"idEpitope,omitempty"`
+    Distance      int        `json:"distance,omitempty"`  // differences of an approximate match
+    Mutations     []Mutation `json:"mutations,omitempty"` // differences of an approximate match
+    Frame           int `json:"frame,omitempty"`    // reading frame of a translated sequence, 1 to 3 or -1 to -3
+    NucleotideStart int `json:"nt_start,omitempty"` // 0-based start on a translated sequence
+    NucleotideEnd   int `json:"nt_end,omitempty"`   // end, excluded, on a translated sequence
+}
+
+// Epitope is an epitope to map, with its database ID and metadata when known
+type Epitope struct {
+    ID             int             `json:"idEpitope,omitempty"`
+    LinearSequence string          `json:"linearSequence"`
+    Metadata       json.RawMessage `json:"metadata,omitempty"`
 }
 
+// UnmarshalJSON accepts either a bare sequence string or an epitope object
+func (e *Epitope) UnmarshalJSON(data []byte) error {
+    if len(data) > 0 && data[0] == '"' {
+        *e = Epitope{}
+        return json.Unmarshal(data, &e.LinearSequence)
+    }
+
+    type plain Epitope
+    var epitope plain
+    if err := json.Unmarshal(data, &epitope); err != nil {
+        return err
+    }
+    if epitope.LinearSequence == "" {
+        return errors.New("epitope without linearSequence")
+    }
+    *e = Epitope(epitope)
+    return nil
+}
 
 // SlideWindow implements the sliding window algorithm for epitope mapping
 func SlideWindow(sequence string, epitopes []string) []EpitopeMap {
+    maps, _ := SlideWindowContext(context.Background(), sequence, epitopes)
+    return maps
+}
+
+// SlideWindowContext is SlideWindow, giving up with the error of ctx once it
+// is done
+func SlideWindowContext(ctx context.Context, sequence string, epitopes []string) ([]EpitopeMap, error) {
+    list := make([]Epitope, len(epitopes))
+    for i, epitope := range epitopes {
+        list[i] = Epitope{LinearSequence: epitope}
+    }
+    return MapEpitopesContext(ctx, sequence, list)
+}
+
+// MapEpitopes maps epitopes like SlideWindow, each EpitopeMap carrying the ID
+// of its epitope
+func MapEpitopes(sequence string, epitopes []Epitope) []EpitopeMap {
+    maps, _ := MapEpitopesContext(context.Background(), sequence, epitopes)
+    return maps
+}
+
+// MapEpitopesContext is MapEpitopes, checking ctx before every epitope
+func MapEpitopesContext(ctx context.Context, sequence string, epitopes []Epitope) ([]EpitopeMap, error) {
     var maps []EpitopeMap
 
     for _, epitope := range epitopes {
-        epitopeLen := len(epitope)
+        if err := ctx.Err(); err != nil {
+            return nil, err
+        }
+
+        epitopeLen := len(epitope.LinearSequence)
         seqLen := len(sequence)
 
         for i := 0; i <= seqLen-epitopeLen; i++ {
             window := sequence[i:i+epitopeLen]
-            if window == epitope {
+            if window == epitope.LinearSequence {
                 maps = append(maps, EpitopeMap{
-                    LinearSequence: epitope,
+                    LinearSequence: epitope.LinearSequence,
                     InitPos:       i,
+                    IDEpitope:     epitope.ID,
                 })
             }
         }
     }
 
-    return maps
+    return maps, nil
 }
 
 // Helper function
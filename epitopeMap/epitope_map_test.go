@@ -2,32 +2,89 @@
 package epitope_map
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 )
 
 func TestSlideWindow(t *testing.T) {
-    tests := []struct {
-        sequence string
-        epitopes []string
-        want     []EpitopeMap
-    }{
-        {
-            sequence: "ATGGCTAGCT",
-            epitopes: []string{"GCT", "AGC"},
-            want: []EpitopeMap{
-                {LinearSequence: "GCT", InitPos: 3},
-                {LinearSequence: "GCT", InitPos: 7},
-                {LinearSequence: "AGC", InitPos: 6},
-            },
-        },
-    }
+	tests := []struct {
+		sequence string
+		epitopes []string
+		want     []EpitopeMap
+	}{
+		{
+			sequence: "ATGGCTAGCT",
+			epitopes: []string{"GCT", "AGC"},
+			want: []EpitopeMap{
+				{LinearSequence: "GCT", InitPos: 3},
+				{LinearSequence: "GCT", InitPos: 7},
+				{LinearSequence: "AGC", InitPos: 6},
+			},
+		},
+	}
 
-    for _, tt := range tests {
-        got := SlideWindow(tt.sequence, tt.epitopes)
-        if !reflect.DeepEqual(got, tt.want) {
-            t.Errorf("SlideWindow(%v, %v) = %v, want %v",
-                tt.sequence, tt.epitopes, got, tt.want)
-        }
-    }
-}
\ No newline at end of file
+	for _, tt := range tests {
+		got := SlideWindow(tt.sequence, tt.epitopes)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SlideWindow(%v, %v) = %v, want %v",
+				tt.sequence, tt.epitopes, got, tt.want)
+		}
+	}
+}
+func TestMapEpitopes(t *testing.T) {
+	epitopes := []Epitope{
+		{ID: 12, LinearSequence: "GCT"},
+		{LinearSequence: "AGC"},
+	}
+	want := []EpitopeMap{
+		{LinearSequence: "GCT", InitPos: 3, IDEpitope: 12},
+		{LinearSequence: "GCT", InitPos: 7, IDEpitope: 12},
+		{LinearSequence: "AGC", InitPos: 6},
+	}
+
+	got := MapEpitopes("ATGGCTAGCT", epitopes)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MapEpitopes(%v) = %v, want %v", epitopes, got, want)
+	}
+}
+
+func TestEpitopeUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    []Epitope
+		wantErr bool
+	}{
+		{
+			data: `["GCT", "AGC"]`,
+			want: []Epitope{{LinearSequence: "GCT"}, {LinearSequence: "AGC"}},
+		},
+		{
+			data: `[{"idEpitope": 12, "linearSequence": "GCT", "metadata": {"source": "iedb"}}, "AGC"]`,
+			want: []Epitope{
+				{ID: 12, LinearSequence: "GCT", Metadata: json.RawMessage(`{"source": "iedb"}`)},
+				{LinearSequence: "AGC"},
+			},
+		},
+		{
+			data:    `[{"idEpitope": 12}]`,
+			wantErr: true,
+		},
+		{
+			data:    `[12]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		var got []Epitope
+		err := json.Unmarshal([]byte(tt.data), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("json.Unmarshal(%v) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("json.Unmarshal(%v) = %+v, want %+v", tt.data, got, tt.want)
+		}
+	}
+}
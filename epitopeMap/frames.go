@@ -0,0 +1,37 @@
+package epitope_map
+
+import "github.com/vsdbmv2/worker-go/translation"
+
+// MapFrames translates a nucleotide sequence in its six reading frames and
+// maps epitopes on each of them with mapPeptide. The maps carry their frame
+// and where they lie on the nucleotide sequence, InitPos being the amino acid
+// position in the peptide of the frame.
+func MapFrames(sequence string, code *translation.GeneticCode, mapPeptide func(peptide string) ([]EpitopeMap, error)) ([]EpitopeMap, error) {
+	var maps []EpitopeMap
+	for _, frame := range code.Frames(sequence) {
+		frameMaps, err := mapPeptide(frame.Peptide)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range frameMaps {
+			m.Frame = frame.Number
+			m.NucleotideStart, m.NucleotideEnd = frame.NucleotideRange(m.InitPos, m.InitPos+m.matchedLength(), len(sequence))
+			maps = append(maps, m)
+		}
+	}
+	return maps, nil
+}
+
+// matchedLength returns the number of residues the map spans in the sequence
+func (m EpitopeMap) matchedLength() int {
+	length := len(m.LinearSequence)
+	for _, mutation := range m.Mutations {
+		switch {
+		case mutation.EpitopeResidue == "":
+			length++
+		case mutation.SequenceResidue == "":
+			length--
+		}
+	}
+	return length
+}
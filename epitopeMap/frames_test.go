@@ -0,0 +1,57 @@
+package epitope_map
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/vsdbmv2/worker-go/translation"
+)
+
+func TestMapFrames(t *testing.T) {
+	// SIINFEKL coded after one base on the sequence and on its reverse complement
+	coding := "AGCATTATCAACTTCGAAAAACTG"
+	sequence := "G" + coding + "TT" + translation.ReverseComplement(coding) + "A"
+
+	tests := []struct {
+		epitopes   []Epitope
+		mapPeptide func(peptide string, epitopes []Epitope) ([]EpitopeMap, error)
+		want       []EpitopeMap
+	}{
+		{
+			epitopes: []Epitope{{ID: 4, LinearSequence: "SIINFEKL"}},
+			mapPeptide: func(peptide string, epitopes []Epitope) ([]EpitopeMap, error) {
+				return NewMatcher(epitopes).Match(peptide), nil
+			},
+			want: []EpitopeMap{
+				{LinearSequence: "SIINFEKL", InitPos: 0, IDEpitope: 4, Frame: 2, NucleotideStart: 1, NucleotideEnd: 25},
+				{LinearSequence: "SIINFEKL", InitPos: 0, IDEpitope: 4, Frame: -2, NucleotideStart: 27, NucleotideEnd: 51},
+			},
+		},
+		{
+			epitopes: []Epitope{{LinearSequence: "SIINFEGKL"}},
+			mapPeptide: func(peptide string, epitopes []Epitope) ([]EpitopeMap, error) {
+				return MapEpitopesApprox(context.Background(), peptide, epitopes, 1, EditDistance)
+			},
+			want: []EpitopeMap{
+				{LinearSequence: "SIINFEGKL", InitPos: 0, Distance: 1, Mutations: []Mutation{{Position: 6, EpitopeResidue: "G"}},
+					Frame: 2, NucleotideStart: 1, NucleotideEnd: 25},
+				{LinearSequence: "SIINFEGKL", InitPos: 0, Distance: 1, Mutations: []Mutation{{Position: 6, EpitopeResidue: "G"}},
+					Frame: -2, NucleotideStart: 27, NucleotideEnd: 51},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := MapFrames(sequence, translation.Standard, func(peptide string) ([]EpitopeMap, error) {
+			return tt.mapPeptide(peptide, tt.epitopes)
+		})
+		if err != nil {
+			t.Errorf("MapFrames(%v, %v) unexpected error %v", sequence, tt.epitopes, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MapFrames(%v, %v) = %+v, want %+v", sequence, tt.epitopes, got, tt.want)
+		}
+	}
+}
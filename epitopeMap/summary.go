@@ -0,0 +1,186 @@
+package epitope_map
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strings"
+)
+
+// EpitopeSummary describes how conserved an epitope is across the sequences
+// of a batch
+type EpitopeSummary struct {
+	IDEpitope      int       `json:"idEpitope,omitempty"`
+	LinearSequence string    `json:"linearSequence"`
+	Sequences      int       `json:"sequences"` // sequences where the epitope was found
+	Frequency      float64   `json:"frequency"` // fraction of the sequences where it was found
+	Entropy        []float64 `json:"entropy"`   // Shannon entropy, in bits, of the residues found at each position
+	Variants       []Variant `json:"variants,omitempty"`
+}
+
+// Variant is a form of an epitope found in the sequences, with the number of
+// sequences it was found in
+type Variant struct {
+	Sequence string `json:"sequence"`
+	Count    int    `json:"count"`
+}
+
+// Summarizer collects the epitope maps of many sequences to summarize the
+// conservation of every epitope. Epitopes differing only in case are the same
+// one, reported as first added, with its residues and variants in uppercase.
+// It is not safe for concurrent use.
+type Summarizer struct {
+	sequences int
+	epitopes  []*epitopeStats
+	index     map[epitopeKey]*epitopeStats
+}
+
+type epitopeKey struct {
+	id       int
+	sequence string
+}
+
+type epitopeStats struct {
+	epitope   epitopeKey
+	sequence  string // as first added
+	sequences int
+	residues  []map[byte]int // residues found at each position, '-' for a deletion
+	variants  map[string]int
+}
+
+// NewSummarizer returns an empty Summarizer
+func NewSummarizer() *Summarizer {
+	return &Summarizer{index: make(map[epitopeKey]*epitopeStats)}
+}
+
+// Add collects the maps of the epitopes on one sequence. Each epitope counts
+// once per sequence, with its closest match.
+func (s *Summarizer) Add(epitopes []Epitope, maps []EpitopeMap) {
+	s.sequences++
+
+	best := make(map[epitopeKey]EpitopeMap)
+	for _, m := range maps {
+		key := epitopeKey{id: m.IDEpitope, sequence: strings.ToUpper(m.LinearSequence)}
+		if found, ok := best[key]; !ok || m.Distance < found.Distance {
+			best[key] = m
+		}
+	}
+
+	for _, epitope := range epitopes {
+		stats := s.stats(epitope)
+		m, ok := best[stats.epitope]
+		if !ok {
+			continue
+		}
+		delete(best, stats.epitope)
+
+		stats.sequences++
+		stats.variants[strings.ToUpper(m.Variant())]++
+		for position, residue := range bytes.ToUpper(m.residues()) {
+			stats.residues[position][residue]++
+		}
+	}
+}
+
+func (s *Summarizer) stats(epitope Epitope) *epitopeStats {
+	key := epitopeKey{id: epitope.ID, sequence: strings.ToUpper(epitope.LinearSequence)}
+	if stats, ok := s.index[key]; ok {
+		return stats
+	}
+	stats := &epitopeStats{
+		epitope:  key,
+		sequence: epitope.LinearSequence,
+		residues: make([]map[byte]int, len(key.sequence)),
+		variants: make(map[string]int),
+	}
+	for i := range stats.residues {
+		stats.residues[i] = make(map[byte]int)
+	}
+	s.epitopes = append(s.epitopes, stats)
+	s.index[key] = stats
+	return stats
+}
+
+// Summary returns the summary of every epitope added so far, in the order
+// they were first added. Variants are sorted by decreasing count.
+func (s *Summarizer) Summary() []EpitopeSummary {
+	summaries := make([]EpitopeSummary, 0, len(s.epitopes))
+	for _, stats := range s.epitopes {
+		summary := EpitopeSummary{
+			IDEpitope:      stats.epitope.id,
+			LinearSequence: stats.sequence,
+			Sequences:      stats.sequences,
+			Entropy:        make([]float64, len(stats.residues)),
+		}
+		if s.sequences > 0 {
+			summary.Frequency = float64(stats.sequences) / float64(s.sequences)
+		}
+		for i, residues := range stats.residues {
+			summary.Entropy[i] = entropy(residues)
+		}
+		for variant, count := range stats.variants {
+			summary.Variants = append(summary.Variants, Variant{Sequence: variant, Count: count})
+		}
+		sort.Slice(summary.Variants, func(a, b int) bool {
+			if summary.Variants[a].Count != summary.Variants[b].Count {
+				return summary.Variants[a].Count > summary.Variants[b].Count
+			}
+			return summary.Variants[a].Sequence < summary.Variants[b].Sequence
+		})
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// entropy returns the Shannon entropy, in bits, of residue counts
+func entropy(counts map[byte]int) float64 {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+
+	var h float64
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// Variant returns the residues of the sequence the epitope was matched to
+func (m EpitopeMap) Variant() string {
+	variant := make([]byte, 0, m.matchedLength())
+	mutations := m.Mutations
+	for position := 0; position <= len(m.LinearSequence); position++ {
+		for len(mutations) > 0 && mutations[0].Position == position && mutations[0].EpitopeResidue == "" {
+			variant = append(variant, mutations[0].SequenceResidue...)
+			mutations = mutations[1:]
+		}
+		if position == len(m.LinearSequence) {
+			break
+		}
+		if len(mutations) > 0 && mutations[0].Position == position {
+			variant = append(variant, mutations[0].SequenceResidue...)
+			mutations = mutations[1:]
+		} else {
+			variant = append(variant, m.LinearSequence[position])
+		}
+	}
+	return string(variant)
+}
+
+// residues returns the residue found at each position of the epitope, '-'
+// where it is deleted
+func (m EpitopeMap) residues() []byte {
+	residues := []byte(m.LinearSequence)
+	for _, mutation := range m.Mutations {
+		switch {
+		case mutation.EpitopeResidue == "":
+		case mutation.SequenceResidue == "":
+			residues[mutation.Position] = '-'
+		default:
+			residues[mutation.Position] = mutation.SequenceResidue[0]
+		}
+	}
+	return residues
+}
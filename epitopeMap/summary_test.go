@@ -0,0 +1,100 @@
+package epitope_map
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSummarizer(t *testing.T) {
+	epitopes := []Epitope{{ID: 1, LinearSequence: "SIINFEKL"}, {ID: 2, LinearSequence: "GILGFVFTL"}}
+	sequences := []string{
+		"MSIINFEKLM",
+		"MSIINFEKLMSIIDFEKL",
+		"MSIIDFEKLM",
+		"MSIIFEKLMM",
+	}
+
+	summarizer := NewSummarizer()
+	for _, sequence := range sequences {
+		maps, err := MapEpitopesApprox(context.Background(), sequence, epitopes, 1, EditDistance)
+		if err != nil {
+			t.Fatalf("MapEpitopesApprox(%v) unexpected error %v", sequence, err)
+		}
+		summarizer.Add(epitopes, maps)
+	}
+
+	// Position 3 is N twice, D once and deleted once
+	want := []EpitopeSummary{
+		{
+			IDEpitope:      1,
+			LinearSequence: "SIINFEKL",
+			Sequences:      4,
+			Frequency:      1,
+			Entropy:        []float64{0, 0, 0, 1.5, 0, 0, 0, 0},
+			Variants: []Variant{
+				{Sequence: "SIINFEKL", Count: 2},
+				{Sequence: "SIIDFEKL", Count: 1},
+				{Sequence: "SIIFEKL", Count: 1},
+			},
+		},
+		{
+			IDEpitope:      2,
+			LinearSequence: "GILGFVFTL",
+			Entropy:        make([]float64, 9),
+		},
+	}
+
+	if got := summarizer.Summary(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Summary() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSummarizerCase(t *testing.T) {
+	summarizer := NewSummarizer()
+	summarizer.Add([]Epitope{{ID: 1, LinearSequence: "siiNFEKL"}},
+		[]EpitopeMap{{LinearSequence: "siiNFEKL", IDEpitope: 1, Distance: 1, Mutations: []Mutation{{Position: 3, EpitopeResidue: "N", SequenceResidue: "D"}}}})
+	summarizer.Add([]Epitope{{ID: 1, LinearSequence: "SIINFEKL"}},
+		[]EpitopeMap{{LinearSequence: "SIINFEKL", IDEpitope: 1}})
+
+	want := []EpitopeSummary{
+		{
+			IDEpitope:      1,
+			LinearSequence: "siiNFEKL",
+			Sequences:      2,
+			Frequency:      1,
+			Entropy:        []float64{0, 0, 0, 1, 0, 0, 0, 0},
+			Variants: []Variant{
+				{Sequence: "SIIDFEKL", Count: 1},
+				{Sequence: "SIINFEKL", Count: 1},
+			},
+		},
+	}
+	if got := summarizer.Summary(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Summary() = %+v, want %+v", got, want)
+	}
+}
+
+func TestEpitopeMapVariant(t *testing.T) {
+	tests := []struct {
+		m    EpitopeMap
+		want string
+	}{
+		{m: EpitopeMap{LinearSequence: "NLVPMVATV"}, want: "NLVPMVATV"},
+		{
+			m: EpitopeMap{LinearSequence: "NLVPMVATV", Mutations: []Mutation{
+				{Position: 0, SequenceResidue: "M"},
+				{Position: 0, EpitopeResidue: "N", SequenceResidue: "D"},
+				{Position: 4, EpitopeResidue: "M"},
+				{Position: 9, SequenceResidue: "Q"},
+			}},
+			want: "MDLVPVATVQ",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.Variant(); got != tt.want {
+			t.Errorf("%+v Variant() = %v, want %v", tt.m, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	. "github.com/vsdbmv2/worker-go/epitopeMap"
+	. "github.com/vsdbmv2/worker-go/processor"
+)
+
+// epitopeBatchExpiry is how long a batch waits for its next job to finish
+// before being summarized incomplete
+const epitopeBatchExpiry = time.Hour
+
+// epitopeBatch summarizes the epitope mapping jobs of a batch as they finish
+type epitopeBatch struct {
+	organism   string
+	size       int
+	done       int
+	updated    time.Time
+	summarizer *Summarizer
+}
+
+var epitopeBatches = struct {
+	sync.Mutex
+	byID map[string]*epitopeBatch
+}{byID: make(map[string]*epitopeBatch)}
+
+// collectEpitopeBatch adds the result of an epitope mapping job to its batch,
+// and once all the jobs of the batch are done sends its epitope-summary
+// result. Failed jobs count as done without adding any sequence. Batches
+// left waiting longer than epitopeBatchExpiry are sent incomplete.
+func collectEpitopeBatch(work Work, result Result, results chan<- Result) {
+	// Jobs of a batch without a size fail with an invalid payload
+	if work.BatchSize <= 0 {
+		return
+	}
+
+	now := time.Now()
+	epitopeBatches.Lock()
+	batch, ok := epitopeBatches.byID[work.BatchID]
+	if !ok {
+		batch = &epitopeBatch{organism: work.Organism, size: work.BatchSize, summarizer: NewSummarizer()}
+		epitopeBatches.byID[work.BatchID] = batch
+	}
+
+	batch.done++
+	batch.updated = now
+	if epitopes, ok := work.Sequence2.Epitopes(); ok && result.Error == nil {
+		batch.summarizer.Add(epitopes, result.EpitopeMaps)
+	}
+
+	var summaries []Result
+	for id, other := range epitopeBatches.byID {
+		if other.done >= other.size || now.Sub(other.updated) > epitopeBatchExpiry {
+			delete(epitopeBatches.byID, id)
+			summaries = append(summaries, other.summary(id))
+		}
+	}
+	epitopeBatches.Unlock()
+
+	for _, summary := range summaries {
+		results <- summary
+	}
+}
+
+// flushEpitopeBatches sends the summaries of the batches still waiting for
+// jobs, which will never finish on this worker once it is shutting down
+func flushEpitopeBatches(results chan<- Result) {
+	epitopeBatches.Lock()
+	var summaries []Result
+	for id, batch := range epitopeBatches.byID {
+		delete(epitopeBatches.byID, id)
+		summaries = append(summaries, batch.summary(id))
+	}
+	epitopeBatches.Unlock()
+
+	for _, summary := range summaries {
+		results <- summary
+	}
+}
+
+// summary returns the epitope-summary result of the batch, failing with an
+// incomplete-batch error when some of its jobs are not done
+func (b *epitopeBatch) summary(id string) Result {
+	result := Result{
+		Type:             EpitopeBatchSummary,
+		Organism:         b.organism,
+		Identifier:       id,
+		EpitopeSummaries: b.summarizer.Summary(),
+	}
+	if b.done < b.size {
+		log.Printf("Summarizing batch %s with %d of its %d jobs done", id, b.done, b.size)
+		result.Error = &JobError{
+			Code:       ErrIncompleteBatch,
+			Message:    fmt.Sprintf("%d of %d jobs done", b.done, b.size),
+			Identifier: id,
+		}
+	}
+	return result
+}
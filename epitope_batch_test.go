@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/vsdbmv2/worker-go/epitopeMap"
+	. "github.com/vsdbmv2/worker-go/processor"
+)
+
+func TestCollectEpitopeBatch(t *testing.T) {
+	epitopes := []Epitope{{ID: 1, LinearSequence: "SIINFEKL"}}
+	found := []EpitopeMap{{LinearSequence: "SIINFEKL", IDEpitope: 1}}
+
+	tests := []struct {
+		name   string
+		size   int
+		failed []bool // whether each job collected failed
+		expire bool   // whether the batch waits past its expiry
+		flush  bool   // whether the batch is flushed on shutdown
+
+		wantSummary   bool
+		wantCode      string // error code of the summary, if incomplete
+		wantSequences int    // sequences the epitope was found in
+	}{
+		{name: "waiting", size: 3, failed: []bool{false, false}},
+		{name: "full", size: 2, failed: []bool{false, false}, wantSummary: true, wantSequences: 2},
+		{name: "failed job", size: 2, failed: []bool{false, true}, wantSummary: true, wantSequences: 1},
+		{name: "stale", size: 3, failed: []bool{false}, expire: true, wantSummary: true, wantCode: ErrIncompleteBatch, wantSequences: 1},
+		{name: "flushed", size: 3, failed: []bool{false, true}, flush: true, wantSummary: true, wantCode: ErrIncompleteBatch, wantSequences: 1},
+		{name: "no size", failed: []bool{false}},
+	}
+
+	for _, tt := range tests {
+		epitopeBatches.byID = make(map[string]*epitopeBatch)
+		results := make(chan Result, 2)
+
+		work := Work{Type: EpitopeMapping, Organism: "virus", BatchID: "batch", BatchSize: tt.size, Sequence2: EpitopePayloadOf(epitopes)}
+		for _, failed := range tt.failed {
+			result := Result{Type: EpitopeMapping, EpitopeMaps: found}
+			if failed {
+				result = Result{Type: EpitopeMapping, Error: &JobError{Code: ErrAlignment}}
+			}
+			collectEpitopeBatch(work, result, results)
+		}
+
+		// Expired batches are summarized as the job of another batch finishes
+		if tt.expire {
+			epitopeBatches.byID["batch"].updated = time.Now().Add(-epitopeBatchExpiry - time.Second)
+			other := Work{Type: EpitopeMapping, BatchID: "other", BatchSize: 2, Sequence2: EpitopePayloadOf(epitopes)}
+			collectEpitopeBatch(other, Result{Type: EpitopeMapping}, results)
+		}
+		if tt.flush {
+			flushEpitopeBatches(results)
+		}
+		close(results)
+
+		var summaries []Result
+		for result := range results {
+			summaries = append(summaries, result)
+		}
+		if !tt.wantSummary {
+			if len(summaries) > 0 {
+				t.Errorf("collectEpitopeBatch(%v) sent %+v, want no summary", tt.name, summaries)
+			}
+			continue
+		}
+		if len(summaries) != 1 {
+			t.Errorf("collectEpitopeBatch(%v) sent %d summaries, want 1", tt.name, len(summaries))
+			continue
+		}
+
+		summary := summaries[0]
+		if summary.Type != EpitopeBatchSummary || summary.Identifier != "batch" || summary.Organism != "virus" {
+			t.Errorf("collectEpitopeBatch(%v) sent %+v, want the epitope-summary of batch", tt.name, summary)
+		}
+		var code string
+		if summary.Error != nil {
+			code = summary.Error.Code
+		}
+		if code != tt.wantCode {
+			t.Errorf("collectEpitopeBatch(%v) summary error = %+v, want code %q", tt.name, summary.Error, tt.wantCode)
+		}
+		if len(summary.EpitopeSummaries) != 1 || summary.EpitopeSummaries[0].Sequences != tt.wantSequences {
+			t.Errorf("collectEpitopeBatch(%v) summarized %+v, want the epitope found in %d sequences", tt.name, summary.EpitopeSummaries, tt.wantSequences)
+		}
+	}
+	epitopeBatches.byID = make(map[string]*epitopeBatch)
+}
@@ -2,56 +2,26 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/joho/godotenv"
-	. "github.com/vsdbmv2/worker-go/epitopeMap"
-	. "github.com/vsdbmv2/worker-go/needlemanWunsh"
-	. "github.com/vsdbmv2/worker-go/smithWaterman"
+	. "github.com/vsdbmv2/worker-go/processor"
+	_ "github.com/vsdbmv2/worker-go/processor/builtin"
+	global_mapping "github.com/vsdbmv2/worker-go/processor/globalMapping"
+	substitution_matrix "github.com/vsdbmv2/worker-go/substitutionMatrix"
 )
 
-// WorkType represents the type of mapping job
-type WorkType string
-
-const (
-    GlobalMapping  WorkType = "global-mapping"
-    LocalMapping   WorkType = "local-mapping"
-    EpitopeMapping WorkType = "epitope-mapping"
-)
-
-// Work represents a single mapping job
-type Work struct {
-    Type       WorkType `json:"type"`
-    ID1        int      `json:"id1"`
-    Organism   string   `json:"organism"`
-    Sequence1  string   `json:"sequence1"`
-    Sequence2  interface{} `json:"sequence2"` // Can be string or []string for epitope mapping
-    ID2        int      `json:"id2"`
-    Identifier string   `json:"identifier"`
-    IDSubtype  int      `json:"idSubtype,omitempty"`
-}
-
-// Result represents the mapping result
-type Result struct {
-    Organism     string     `json:"organism"`
-    Type         WorkType   `json:"type"`
-    Identifier   string     `json:"identifier"`
-    MapInit      int       `json:"map_init,omitempty"`
-    MapEnd       int       `json:"map_end,omitempty"`
-    CoveragePct  float64   `json:"coverage_pct,omitempty"`
-    IDSequence   int       `json:"idSequence,omitempty"`
-    AlignmentScore int     `json:"alignment_score,omitempty"`
-    IDSequenceSubtype int  `json:"idSequenceSubtype,omitempty"`
-    IDSubtype    int       `json:"idSubtype,omitempty"`
-    EpitopeMaps  []EpitopeMap `json:"epitope_maps,omitempty"`
-}
-
 func main() {
     // Load environment variables
     if err := godotenv.Load(); err != nil {
@@ -61,7 +31,7 @@ func main() {
     // Get max concurrency
     maxConcurrency := runtime.NumCPU()
     if maxConcurrencyEnv := os.Getenv("maxConcurrency"); maxConcurrencyEnv != "" {
-        if mc, err := strconv.Atoi(maxConcurrencyEnv); err == nil {
+        if mc, err := strconv.Atoi(maxConcurrencyEnv); err == nil && mc > 0 {
             maxConcurrency = mc
         }
     }
@@ -72,158 +42,244 @@ func main() {
         wsHost = wsHostEnv
     }
 
-    // Connect to WebSocket
-    c, _, err := websocket.DefaultDialer.Dial(wsHost, nil)
-    if err != nil {
-        log.Fatal("WebSocket connection error:", err)
+    // Load extra NCBI-format substitution matrices, named after their files
+    if matrixDir := os.Getenv("matrixDir"); matrixDir != "" {
+        if err := loadMatrices(matrixDir); err != nil {
+            log.Fatal("Substitution matrix error:", err)
+        }
     }
-    defer c.Close()
 
-    log.Println("Connected to WebSocket server")
+    // Let works read sequence files from this directory
+    if dataDir := os.Getenv("dataDir"); dataDir != "" {
+        SetDataDir(dataDir)
+    }
 
-    // Channel for work results
-    results := make(chan Result)
-    var activeWorks int
+    // Get the matrix size above which global mappings save memory
+    if thresholdEnv := os.Getenv("linearSpaceThreshold"); thresholdEnv != "" {
+        if lt, err := strconv.Atoi(thresholdEnv); err == nil {
+            global_mapping.SetLinearSpaceThreshold(lt)
+        }
+    }
 
-    for {
-        _, message, err := c.ReadMessage()
-        if err != nil {
-            log.Println("WebSocket read error:", err)
-            break
+    // Run an offline command instead of serving, e.g. worker align-global
+    if len(os.Args) > 1 {
+        os.Exit(runCommand(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+    }
+
+    // Get job queue size
+    queueSize := maxConcurrency * 2
+    if queueSizeEnv := os.Getenv("queueSize"); queueSizeEnv != "" {
+        if qs, err := strconv.Atoi(queueSizeEnv); err == nil && qs >= 0 {
+            queueSize = qs
         }
+    }
 
-        var event struct {
-            Type    string          `json:"type"`
-            Payload json.RawMessage `json:"payload"`
+    // Get how long running jobs may take to finish on shutdown, in seconds,
+    // below the 10 seconds docker stop waits by default
+    gracePeriod := 8 * time.Second
+    if graceEnv := os.Getenv("shutdownGracePeriod"); graceEnv != "" {
+        if gp, err := strconv.Atoi(graceEnv); err == nil && gp >= 0 {
+            gracePeriod = time.Duration(gp) * time.Second
         }
+    }
 
-        if err := json.Unmarshal(message, &event); err != nil {
-            log.Println("JSON parse error:", err)
-            continue
+    // Stop asking for work on SIGTERM or an interrupt
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+    defer stop()
+
+    c := newConnection(wsHost)
+
+    // Results are streamed back by a dedicated goroutine so that none of them
+    // has to wait for the next server message
+    results := make(chan Result)
+    dispatched := make(chan struct{})
+    go func() {
+        dispatchResults(c, results)
+        close(dispatched)
+    }()
+
+    workers := newPool(maxConcurrency, queueSize, results)
+
+    // Serve Prometheus metrics when an address is given, e.g. :9090
+    if metricsAddr := os.Getenv("metricsAddr"); metricsAddr != "" {
+        serveMetrics(metricsAddr, workers)
+    }
+
+    // Announce the free worker capacity and the queue depth to the server,
+    // asking for no works while every worker is busy, and for nothing at all
+    // once shutting down
+    requestWork := func() {
+        if ctx.Err() != nil {
+            return
+        }
+        response := struct {
+            WorksAmount int `json:"worksAmount"`
+            QueueDepth  int `json:"queueDepth"`
+        }{
+            WorksAmount: workers.available(),
+            QueueDepth:  workers.queueDepth(),
         }
+        if err := c.send("get-work", response); err != nil {
+            log.Println("WebSocket write error:", err)
+        }
+    }
 
+    // Works received but never queued, to hand back to the server
+    var unqueuedMu sync.Mutex
+    unqueued := []string{}
+
+    handle := func(event inbound) {
         switch event.Type {
         case "work":
             var works []Work
             if err := json.Unmarshal(event.Payload, &works); err != nil {
                 log.Println("Work parse error:", err)
-                continue
+                return
             }
 
-            // Process works concurrently
-            activeWorks = len(works)
+            // Queue works on the pool, which never blocks the read loop
+            unqueuedMu.Lock()
+            defer unqueuedMu.Unlock()
             for _, work := range works {
-                go processWork(work, results)
+                if !workers.submit(ctx, work) {
+                    unqueued = append(unqueued, work.Identifier)
+                }
             }
 
-        case "ping":
-            if activeWorks == 0 {
-                response := struct {
-                    WorksAmount int `json:"worksAmount"`
-                }{
-                    WorksAmount: maxConcurrency,
+        case "cancel-work":
+            var identifiers []string
+            if err := json.Unmarshal(event.Payload, &identifiers); err != nil {
+                log.Println("Cancel parse error:", err)
+                return
+            }
+
+            // Abort the queued and running jobs with these identifiers
+            for _, identifier := range identifiers {
+                if n := workers.cancel(identifier); n > 0 {
+                    log.Printf("Canceled %d jobs %s", n, identifier)
                 }
-                c.WriteJSON(map[string]interface{}{
-                    "type":    "get-work",
-                    "payload": response,
-                })
             }
+
+        case "ping":
+            requestWork()
         }
+    }
 
-        // Collect results
-        select {
-        case result := <-results:
-            activeWorks--
-            if activeWorks == 0 {
-                c.WriteJSON(map[string]interface{}{
-                    "type":    "work-complete",
-                    "payload": result,
-                })
+    for connections := 0; ctx.Err() == nil; connections++ {
+        // Connect to WebSocket, retrying until the server is reachable
+        ws, err := c.connect(ctx)
+        if err != nil {
+            break
+        }
+        log.Println("Connected to WebSocket server")
+        if connections > 0 {
+            reconnects.Inc()
+        }
+
+        // Events are read until the connection drops, including while the
+        // pool drains on shutdown, so that pings are still answered and
+        // cancel-work events still abort jobs
+        disconnected := make(chan struct{})
+        go func() {
+            err := readEvents(ws, handle)
+            if c.online() {
+                log.Println("WebSocket read error:", err)
             }
-        default:
+            c.detach(ws)
+            close(disconnected)
+        }()
+
+        // Tell the server which work types this worker can run
+        capabilities := struct {
+            WorkTypes []WorkType `json:"workTypes"`
+        }{WorkTypes: WorkTypes()}
+        if err := c.send("worker-capabilities", capabilities); err != nil {
+            log.Println("WebSocket write error:", err)
+        }
+        requestWork()
+
+        select {
+        case <-ctx.Done():
+        case <-disconnected:
+            log.Println("Disconnected from WebSocket server, reconnecting")
         }
     }
 
-    log.Println("Disconnected from WebSocket server")
+    // Works still arriving are handed back too, until the connection closes,
+    // the lock staying held for no more of them to be queued
+    shutdown(c, workers, gracePeriod, results, dispatched, func() []string {
+        unqueuedMu.Lock()
+        return unqueued
+    })
+    defer unqueuedMu.Unlock()
 }
 
-func processGlobalMapping(work Work) Result {
-  if sequence, ok := work.Sequence2.(string); ok {
-    /* act on str */
-    startPos, endPos, coverage, err := NeedlemanWunsch(work.Sequence1, sequence)
+// shutdown drains workers, waiting grace at most for the jobs running, sends
+// the results left and hands the works not finished back to the server,
+// after the ones unqueued returns, then closes the connection. The worker
+// redials right away for grace at most if it is offline, the results held
+// being lost otherwise.
+func shutdown(c *connection, workers *pool, grace time.Duration, results chan Result, dispatched <-chan struct{}, unqueued func() []string) {
+  log.Println("Shutting down, waiting for running jobs")
+  unfinished := workers.drain(grace)
+  flushEpitopeBatches(results)
+  close(results)
+  <-dispatched
 
-    if err != nil {
-      fmt.Println(err)
+  // Deliver the last results, reconnecting for a while if need be
+  if !c.online() {
+    reconnect, cancel := context.WithTimeout(context.Background(), grace)
+    if _, err := c.connectNow(reconnect); err != nil {
+      log.Println("WebSocket connection error:", err)
     }
+    cancel()
+  }
+  unfinished = append(unqueued(), unfinished...)
 
-    return Result{
-      Type: work.Type,
-      Organism: work.Organism,
-      MapInit: startPos,
-      MapEnd: endPos,
-      Identifier: work.Identifier,
-      CoveragePct: coverage,
-      IDSequence: work.ID2,
-    }
-  } else {
-    panic("String expected in global mapping")
+  // Hand the works not finished back to the server for reassignment
+  leaving := struct {
+    Unfinished []string `json:"unfinished"`
+  }{Unfinished: unfinished}
+  if err := c.leave("worker-leaving", leaving); err != nil {
+    log.Println("WebSocket write error:", err)
   }
+  log.Printf("Shut down, %d works unfinished", len(unfinished))
 }
-func processLocalMapping(work Work) Result {
-  if sequence, ok := work.Sequence2.(string); ok {
-    /* act on str */
-    score, err := SmithWaterman(work.Sequence1, sequence)
+
+// loadMatrices registers every substitution matrix file found in dir
+func loadMatrices(dir string) error {
+    entries, err := os.ReadDir(dir)
     if err != nil {
-      fmt.Println(err)
+        return err
     }
-
-    return Result{
-      Type: work.Type,
-      Organism: work.Organism,
-      Identifier: work.Identifier,
-      AlignmentScore: score,
-      IDSequence: work.ID1,
-      IDSequenceSubtype: work.ID2,
-      IDSubtype: work.IDSubtype,
+    for _, entry := range entries {
+        if entry.IsDir() {
+            continue
+        }
+        matrix, err := substitution_matrix.Load(filepath.Join(dir, entry.Name()))
+        if err != nil {
+            return err
+        }
+        substitution_matrix.Register(matrix)
+        log.Println("Loaded substitution matrix", matrix.Name)
     }
-  } else {
-    panic("String expected in local mapping")
-  }
-}
-func processEpitopeMapping(work Work) Result {
-  epitopes := make([]string, 0)
-  switch work.Sequence2.(type) {
-  case string:
-    epitopes = append(epitopes, work.Sequence2.(string))
-  case []string:
-    epitopes = work.Sequence2.([]string)
-  default:
-    panic("Expected string or string array for epitopes")
-  }
-  mapped := SlideWindow(work.Sequence1, epitopes)
-
-  return Result{
-    Type: work.Type,
-    Organism: work.Organism,
-    Identifier: work.Identifier,
-    IDSequence: work.ID1,
-    IDSequenceSubtype: work.ID2,
-    IDSubtype: work.IDSubtype,
-    EpitopeMaps: mapped,
-  }
+    return nil
 }
 
-func processWork(work Work, results chan<- Result) {
-    var result Result
-    
-    switch work.Type {
-    case GlobalMapping:
-        result = processGlobalMapping(work)
-    case LocalMapping:
-        result = processLocalMapping(work)
-    case EpitopeMapping:
-        result = processEpitopeMapping(work)
+// processWork runs a single job on its registered processor and reports back
+// on results, failed jobs included, returning whether it did. A job canceled
+// by a shutdown reports nothing, being handed back to the server instead.
+func processWork(ctx context.Context, work Work, results chan<- Result) bool {
+    start := time.Now()
+    result, loaded := ProcessLoaded(ctx, work)
+    observeJob(loaded, result, time.Since(start))
+    if result.Error != nil && result.Error.Code == ErrCanceled && errors.Is(context.Cause(ctx), errShuttingDown) {
+        return false
     }
-
     results <- result
+
+    if work.Type == EpitopeMapping && work.BatchID != "" {
+        collectEpitopeBatch(loaded, result, results)
+    }
+    return true
 }
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	needleman_wunsh "github.com/vsdbmv2/worker-go/needlemanWunsh"
+	. "github.com/vsdbmv2/worker-go/processor"
+	global_mapping "github.com/vsdbmv2/worker-go/processor/globalMapping"
+)
+
+const metricsNamespace = "vsdbm_worker"
+
+var (
+	jobsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs_processed_total",
+		Help:      "Jobs processed, failed ones included, by work type.",
+	}, []string{"type"})
+
+	jobFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "job_failures_total",
+		Help:      "Jobs that failed, by work type and error code.",
+	}, []string{"type", "code"})
+
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "job_duration_seconds",
+		Help:      "Time spent processing a job, by work type.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10), // 1ms to about 4 minutes
+	}, []string{"type"})
+
+	cellsComputed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "dp_cells_computed_total",
+		Help:      "Cells scored in the dynamic programming matrices of alignments, only the band of banded ones, by work type. Its rate is the cells computed per second.",
+	}, []string{"type"})
+
+	matrixBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "dp_matrix_bytes_allocated_total",
+		Help:      "Estimated bytes allocated for the dynamic programming matrices of alignments, by work type.",
+	}, []string{"type"})
+
+	reconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconnects_total",
+		Help:      "Reconnections to the server after losing the connection.",
+	})
+
+	connectionFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "connection_failures_total",
+		Help:      "Failed attempts to connect to the server.",
+	})
+)
+
+// serveMetrics exposes the metrics of the worker and of its pool on addr,
+// under /metrics
+func serveMetrics(addr string, workers *pool) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "queue_depth",
+		Help:      "Jobs waiting for a free worker.",
+	}, func() float64 { return float64(workers.queueDepth()) })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs_in_flight",
+		Help:      "Jobs currently being processed.",
+	}, func() float64 { return float64(workers.inFlight()) })
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Println("Metrics server error:", err)
+		}
+	}()
+	log.Println("Serving metrics on", addr)
+}
+
+// observeJob records a finished job, which took elapsed. Jobs of a work type
+// no processor is registered for are labeled unsupported, work types coming
+// from the server.
+func observeJob(work Work, result Result, elapsed time.Duration) {
+	workType := string(work.Type)
+	if _, ok := Lookup(work.Type); !ok {
+		workType = "unsupported"
+	}
+	jobsProcessed.WithLabelValues(workType).Inc()
+	jobDuration.WithLabelValues(workType).Observe(elapsed.Seconds())
+	if result.Error != nil {
+		jobFailures.WithLabelValues(workType, result.Error.Code).Inc()
+		return
+	}
+
+	if cells, bytes := matrixSize(work, result); cells > 0 {
+		cellsComputed.WithLabelValues(workType).Add(float64(cells))
+		matrixBytes.WithLabelValues(workType).Add(float64(bytes))
+	}
+}
+
+// matrixSize returns the cells of the alignment matrix a job scored, and an
+// estimate of the bytes allocated for it, score rows included. Global and
+// glocal mappings keep a traceback byte per cell, or per cell of the largest
+// block in linear space, and banded ones per cell of the band, the whole
+// matrix being scored again only when the band may not hold the alignment.
+// Local mappings score the whole matrix on the rows of the striped kernel,
+// with a query profile per reference residue, then trace back the part of it
+// holding the alignment.
+func matrixSize(work Work, result Result) (int, int) {
+	sequence, ok := work.Sequence2.Single()
+	if !ok {
+		return 0, 0
+	}
+	rows, cols := len(work.Sequence1)+1, len(sequence)+1
+	cells := rows * cols
+
+	switch work.Type {
+	case GlobalMapping, GlocalMapping:
+		// Global mappings run the longer sequence down the rows, glocal ones
+		// the query
+		if work.Type == GlocalMapping || rows < cols {
+			rows, cols = cols, rows
+		}
+		threshold := global_mapping.LinearSpaceThreshold()
+
+		// Bands too large for linear space are aligned on the whole matrix
+		if work.Band != nil {
+			width := work.Band.Width
+			if width == 0 {
+				width = needleman_wunsh.DefaultBandWidth
+			}
+			band := rows * min(2*width+1, cols)
+			if threshold <= 0 || band <= threshold {
+				return band, band + 3*8*rows + 2*8*cols
+			}
+		}
+
+		trace := cells
+		if threshold > 0 && cells > threshold {
+			trace = threshold
+		}
+		return cells, trace + 2*8*cols
+	case LocalMapping:
+		var seen [256]bool
+		profiles := 0
+		for _, residue := range []byte(strings.ToUpper(work.Sequence1)) {
+			if !seen[residue] {
+				seen[residue] = true
+				profiles++
+			}
+		}
+		bytes := (5+profiles)*4*cols + 2*8*cols
+		if result.QueryEnd > result.QueryStart {
+			tracedCols := result.QueryEnd - result.QueryStart + 1
+			bytes += (result.RefEnd-result.RefStart+1)*tracedCols + 2*8*tracedCols
+		}
+		return cells, bytes
+	}
+	return 0, 0
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	needleman_wunsh "github.com/vsdbmv2/worker-go/needlemanWunsh"
+	. "github.com/vsdbmv2/worker-go/processor"
+)
+
+func TestMatrixSize(t *testing.T) {
+	reference, query := "ACGTACGTACGTACGTACGT", "ACGTTCGTACGTACG"
+	aligned := Result{RefStart: 2, RefEnd: 12, QueryStart: 0, QueryEnd: 10}
+
+	tests := []struct {
+		name      string
+		work      Work
+		result    Result
+		wantCells int
+		wantBytes int
+	}{
+		{
+			name:      "global",
+			work:      Work{Type: GlobalMapping, Sequence1: reference, Sequence2: SequencePayloadOf(query)},
+			wantCells: 21 * 16,
+			wantBytes: 21*16 + 2*8*16,
+		},
+		{
+			name:      "banded global",
+			work:      Work{Type: GlobalMapping, Sequence1: reference, Sequence2: SequencePayloadOf(query), Band: &needleman_wunsh.BandParams{Width: 2}},
+			wantCells: 21 * 5,
+			wantBytes: 21*5 + 3*8*21 + 2*8*16,
+		},
+		{
+			name:      "glocal",
+			work:      Work{Type: GlocalMapping, Sequence1: reference, Sequence2: SequencePayloadOf(query)},
+			wantCells: 21 * 16,
+			wantBytes: 21*16 + 2*8*21,
+		},
+		{
+			name:      "local",
+			work:      Work{Type: LocalMapping, Sequence1: reference, Sequence2: SequencePayloadOf(query)},
+			result:    aligned,
+			wantCells: 21 * 16,
+			wantBytes: (5+4)*4*16 + 2*8*16 + 11*11 + 2*8*11,
+		},
+		{
+			name:      "local without alignment",
+			work:      Work{Type: LocalMapping, Sequence1: "AAAA", Sequence2: SequencePayloadOf("CC")},
+			wantCells: 5 * 3,
+			wantBytes: (5+1)*4*3 + 2*8*3,
+		},
+		{
+			name: "epitope mapping",
+			work: Work{Type: EpitopeMapping, Sequence1: reference},
+		},
+	}
+
+	for _, tt := range tests {
+		cells, bytes := matrixSize(tt.work, tt.result)
+		if cells != tt.wantCells || bytes != tt.wantBytes {
+			t.Errorf("matrixSize(%v) = %d, %d, want %d, %d", tt.name, cells, bytes, tt.wantCells, tt.wantBytes)
+		}
+	}
+}
+
+func TestObserveJobUnsupported(t *testing.T) {
+	before := testutil.ToFloat64(jobsProcessed.WithLabelValues("unsupported"))
+	observeJob(Work{Type: "made-up"}, Result{Error: &JobError{Code: ErrUnsupportedWorkType}}, time.Millisecond)
+	if got := testutil.ToFloat64(jobsProcessed.WithLabelValues("unsupported")) - before; got != 1 {
+		t.Errorf("unsupported jobs counted %v times, want 1", got)
+	}
+}
@@ -0,0 +1,248 @@
+package needleman_wunsh
+
+import (
+	"errors"
+	"math"
+)
+
+// DefaultBandWidth is the band width of banded alignments giving none
+const DefaultBandWidth = 100
+
+// BandParams asks for a banded alignment, which only scores the cells near
+// the diagonal joining the corners of the matrix. It suits closely related
+// sequences, like a subtype sequence and its reference, whose alignment
+// stays near that diagonal.
+type BandParams struct {
+	Width int `json:"width,omitempty"` // diagonals on each side of the main one, DefaultBandWidth when 0
+	XDrop int `json:"xDrop,omitempty"` // rows also stop at cells scoring this much below the best score so far, never when 0
+}
+
+// Validate reports whether the band can be used for an alignment
+func (p BandParams) Validate() error {
+	if p.Width < 0 || p.XDrop < 0 {
+		return errors.New("band width and X-drop must not be negative")
+	}
+	return nil
+}
+
+// WithBand aligns within a band around the diagonal. When a path leaving
+// the band could score as much as the alignment found inside it, the whole
+// matrix is scored to check that alignment, and aligned instead unless it
+// gives the same one.
+func WithBand(params BandParams) Option {
+	return func(c *config) {
+		c.band = &params
+	}
+}
+
+// alignBanded performs the alignment of process and traceBack over the
+// cells of the band only, returning false when no band was asked for, when
+// its traceback would not fit the linear space threshold, or when the band
+// does not hold the alignment.
+//
+// The cells of each row are scored as in fill, those outside the band
+// scoring -Inf. With an X-drop, each row also stops short of the cells at
+// its ends that scored too low in the row above, narrowing the band where
+// the alignment is clear. A path of the whole matrix through the cells
+// outside the band starts outside it or leaves it from a cell next to its
+// edge, so it scores at most 0 or that cell, plus the best pair score for
+// every pair of residues left after it. When all of these bounds are below
+// the best score of the band, no such path reaches or ties with the best
+// cell or the cells traced back from it, and the band holds the alignment.
+// Otherwise matrixAgrees checks it.
+func alignBanded(rowString, columnString string, cfg config) (traceBackResult, bool, error) {
+	if cfg.band == nil {
+		return traceBackResult{}, false, nil
+	}
+	M, Ms, G, Ge := cfg.scoring.Match, cfg.scoring.Mismatch, cfg.scoring.GapOpen, cfg.scoring.GapExtension
+	m := len(rowString) + 1
+	n := len(columnString) + 1
+	width := cfg.band.Width
+	if width == 0 {
+		width = DefaultBandWidth
+	}
+	if cfg.linearSpaceThreshold > 0 && m*min(2*width+1, n) > cfg.linearSpaceThreshold {
+		return traceBackResult{}, false, nil
+	}
+
+	// The moves of row i are those of columns lo[i] through hi[i], starting
+	// at offsets[i] + lo[i] in trace. Row 0 is left out, its moves being
+	// those of the boundary.
+	lo := make([]int, m)
+	hi := make([]int, m)
+	offsets := make([]int, m)
+	trace := make([]byte, 0, m*min(2*width+1, n))
+	lo[0], hi[0] = 1, n-1
+
+	// outside bounds the scores of the paths through the cells outside the
+	// band, each pair of residues left after cell (i, j) adding at most gain
+	gain := float64(max(cfg.maxScore(), 0))
+	outside := math.Inf(-1)
+	bound := func(score float64, i, j int) {
+		outside = math.Max(outside, score+gain*float64(min(m-1-i, n-1-j)))
+	}
+
+	v, g := firstRow(n)
+	best := processResult{score: math.Inf(-1)}
+	liveLo, liveHi := 1, n-1
+	for i := 1; i < m; i++ {
+		if err := cfg.ctx.Err(); err != nil {
+			return traceBackResult{}, false, err
+		}
+
+		center := i * (n - 1) / (m - 1)
+		rowLo, rowHi := max(1, center-width, liveLo), min(n-1, center+width, liveHi+1)
+		if rowLo > rowHi {
+			return traceBackResult{}, false, nil
+		}
+
+		// Paths leaving the cells of the row above for cells of this row
+		// outside the band, or starting from column 0 outside it
+		for j := lo[i-1]; j <= hi[i-1]; j++ {
+			if j < rowLo || j >= rowHi && rowHi < n-1 {
+				bound(v[j], i-1, j)
+			}
+		}
+		if rowLo > 1 {
+			bound(0, i-1, 0)
+		}
+
+		v[0] = float64(-G - (i-1)*Ge)
+		vDiagonal, vLeft := 0.0, v[0]
+		if rowLo > 1 {
+			vDiagonal, vLeft = v[rowLo-1], math.Inf(-1)
+		}
+		h := math.Inf(-1)
+		offsets[i] = len(trace) - rowLo
+		liveLo, liveHi = n, 0
+
+		for j := rowLo; j <= rowHi; j++ {
+			var cell byte
+			similarityScore := Ms
+			if cfg.matrix != nil {
+				similarityScore = cfg.matrix.Score(rowString[i-1], columnString[j-1])
+			} else if rowString[i-1] == columnString[j-1] {
+				similarityScore = M
+			}
+
+			f := vDiagonal + float64(similarityScore)
+
+			// From left
+			if h-float64(Ge) >= vLeft-float64(G) {
+				h -= float64(Ge)
+				cell |= leftGapExt
+			} else {
+				h = vLeft - float64(G)
+			}
+
+			// From above
+			if g[j]-float64(Ge) >= v[j]-float64(G) {
+				g[j] = g[j] - float64(Ge)
+				cell |= upGapExt
+			} else {
+				g[j] = v[j] - float64(G)
+			}
+
+			vDiagonal = v[j]
+			v[j] = math.Max(f, math.Max(g[j], h))
+			vLeft = v[j]
+
+			if v[j] > best.score {
+				best.score = v[j]
+				best.maxi = i
+				best.maxj = j
+			}
+			if cfg.band.XDrop == 0 || v[j] >= best.score-float64(cfg.band.XDrop) {
+				liveLo, liveHi = min(liveLo, j), j
+			}
+
+			if v[j] == f {
+				cell |= diagonal
+			} else if v[j] == g[j] && v[j] != 0 {
+				cell |= up
+			} else if v[j] == h && v[j] != 0 {
+				cell |= left
+			}
+			trace = append(trace, cell)
+		}
+		if rowHi < n-1 {
+			bound(v[rowHi], i, rowHi)
+		}
+
+		// The cells of the row above left out of this row are outside the band
+		for j := lo[i-1]; j <= hi[i-1]; j++ {
+			if j < rowLo || j > rowHi {
+				v[j], g[j] = math.Inf(-1), math.Inf(-1)
+			}
+		}
+		lo[i], hi[i] = rowLo, rowHi
+	}
+
+	var traced []tracedCell
+	cells := func(i, j int) byte {
+		if i == 0 || j == 0 {
+			return boundaryMove(i, j)
+		}
+		move := trace[offsets[i]+j]
+		traced = append(traced, tracedCell{i: i, j: j, move: move})
+		return move
+	}
+	alignment := tail(rowString, columnString, best.maxi, best.maxj)
+	i, j, _ := walk(rowString, columnString, cells, 0, best.maxi, best.maxj, diagonal, &alignment)
+	if outside >= best.score {
+		agrees, err := matrixAgrees(rowString, columnString, cfg, best, traced)
+		if err != nil || !agrees {
+			return traceBackResult{}, false, err
+		}
+	}
+	return finish(alignment, i, j), true, nil
+}
+
+// tracedCell is a cell of the band the traceback went through, with its move
+type tracedCell struct {
+	i, j int
+	move byte
+}
+
+// matrixAgrees scores the whole matrix a row at a time, reporting whether
+// its best cell is that of the band, and the cells traced back from it, in
+// decreasing rows, have the same moves. The traceback of the whole matrix is
+// then the one of the band.
+func matrixAgrees(rowString, columnString string, cfg config, best processResult, traced []tracedCell) (bool, error) {
+	m := len(rowString) + 1
+	n := len(columnString) + 1
+	v, g := firstRow(n)
+	trace := make([]byte, 2*n)
+	whole := processResult{score: math.Inf(-1)}
+	for i := 1; i < m; i++ {
+		if err := fill(rowString, columnString, i, i, v, g, trace, cfg, &whole); err != nil {
+			return false, err
+		}
+		for len(traced) > 0 && traced[len(traced)-1].i == i {
+			cell := traced[len(traced)-1]
+			if trace[n+cell.j] != cell.move {
+				return false, nil
+			}
+			traced = traced[:len(traced)-1]
+		}
+	}
+	return whole == best, nil
+}
+
+// boundaryMove returns the move of a cell of row 0 or column 0, as set by
+// initBoundaries
+func boundaryMove(i, j int) byte {
+	switch {
+	case j > 0:
+		if j > 1 {
+			return left | leftGapExt
+		}
+		return left
+	case i > 0:
+		if i > 1 {
+			return up | upGapExt
+		}
+		return up
+	}
+	return stop
+}
@@ -0,0 +1,118 @@
+package needleman_wunsh
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestAlignBanded(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	type testCase struct {
+		seq1 string
+		seq2 string
+		band BandParams
+	}
+	var tests []testCase
+	for k := 0; k < 30; k++ {
+		reference := randomSequence(r, 100+r.Intn(1000))
+		related := mutate(r, reference, 1+r.Intn(10))
+		tests = append(tests,
+			testCase{seq1: reference, seq2: related, band: BandParams{Width: 32}},
+			testCase{seq1: reference, seq2: related, band: BandParams{Width: 32, XDrop: 40}},
+			testCase{seq1: reference, seq2: related, band: BandParams{}},
+		)
+	}
+	for k := 0; k < 10; k++ {
+		reference := randomSequence(r, 20+r.Intn(200))
+		tests = append(tests,
+			testCase{seq1: reference, seq2: randomSequence(r, 1+r.Intn(len(reference))), band: BandParams{Width: 8, XDrop: 10}})
+	}
+
+	for _, tt := range tests {
+		want, err := Align(tt.seq1, tt.seq2)
+		if err != nil {
+			t.Fatalf("Align(%v, %v) unexpected error %v", tt.seq1, tt.seq2, err)
+		}
+		got, err := Align(tt.seq1, tt.seq2, WithBand(tt.band))
+		if err != nil {
+			t.Errorf("Align(%v, %v) with band %+v unexpected error %v", tt.seq1, tt.seq2, tt.band, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Align(%v, %v) with band %+v = %+v, want %+v", tt.seq1, tt.seq2, tt.band, got, want)
+		}
+	}
+}
+
+func TestAlignBandedFallback(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	reference := randomSequence(r, 600)
+	related := reference[:100] + "A" + reference[101:300] + reference[308:450] + randomSequence(r, 5) + reference[450:]
+	deleted := reference[:200] + reference[300:]
+
+	tests := []struct {
+		name string
+		seq2 string
+		band BandParams
+		want bool
+	}{
+		{name: "related", seq2: related, band: BandParams{Width: 16}, want: true},
+		{name: "related with X-drop", seq2: related, band: BandParams{Width: 16, XDrop: 40}, want: true},
+		{name: "related with X-drop only", seq2: related, band: BandParams{XDrop: 40}, want: true},
+		{name: "far from the diagonal", seq2: reference[400:500], band: BandParams{Width: 16}},
+		{name: "deletion longer than the band", seq2: deleted, band: BandParams{Width: 16}},
+		{name: "deletion dropped", seq2: deleted, band: BandParams{Width: 16, XDrop: 20}},
+		{name: "unrelated", seq2: randomSequence(r, 600), band: BandParams{Width: 8, XDrop: 10}},
+	}
+
+	for _, tt := range tests {
+		want, err := Align(reference, tt.seq2)
+		if err != nil {
+			t.Fatalf("Align(%v) unexpected error %v", tt.name, err)
+		}
+		got, err := Align(reference, tt.seq2, WithBand(tt.band))
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Align(%v) with band %+v = %+v, %v, want %+v", tt.name, tt.band, got, err, want)
+		}
+
+		cfg := newConfig([]Option{WithBand(tt.band)})
+		if _, banded, _ := alignBanded(reference, tt.seq2, cfg); banded != tt.want {
+			t.Errorf("alignBanded(%v) with band %+v held = %v, want %v", tt.name, tt.band, banded, tt.want)
+		}
+	}
+}
+
+// Indels wider than the band used to leave it without touching its edge
+func TestAlignBandedWideIndels(t *testing.T) {
+	r := rand.New(rand.NewSource(17))
+	for k := 0; k < 100; k++ {
+		band := BandParams{Width: 2 + r.Intn(12)}
+		if k%3 == 0 {
+			band.XDrop = 10 + r.Intn(30)
+		}
+		reference := randomSequence(r, 60+r.Intn(400))
+		position := r.Intn(len(reference) - 30)
+		indel := band.Width + 1 + r.Intn(2*band.Width)
+		query := reference[:position] + reference[min(position+indel, len(reference)):]
+		if k%2 == 0 {
+			query = reference[:position] + randomSequence(r, indel) + reference[position:]
+		}
+		query = mutate(r, query, r.Intn(4))
+
+		want, err := Align(reference, query)
+		if err != nil {
+			t.Fatalf("Align(%v, %v) unexpected error %v", reference, query, err)
+		}
+		got, err := Align(reference, query, WithBand(band))
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Align(%v, %v) with band %+v = %+v, %v, want %+v", reference, query, band, got, err, want)
+		}
+	}
+}
+
+func TestAlignBandedInvalid(t *testing.T) {
+	if _, err := Align("ACGT", "ACGT", WithBand(BandParams{Width: -1})); err == nil {
+		t.Errorf("Align() with a negative band width returned no error")
+	}
+}
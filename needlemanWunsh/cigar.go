@@ -0,0 +1,122 @@
+package needleman_wunsh
+
+import (
+	"strconv"
+	"strings"
+)
+
+// EditOperation is a single difference between the query and the reference,
+// with 0-based positions on both sequences.
+type EditOperation struct {
+	Op             string `json:"op"` // X substitution, I insertion or D deletion
+	ReferencePos   int    `json:"ref_pos"`
+	QueryPos       int    `json:"query_pos"`
+	ReferenceBases string `json:"ref,omitempty"`
+	QueryBases     string `json:"query,omitempty"`
+}
+
+// alignedColumns returns the first and one past the last column of the
+// alignment where the query has a residue, dropping the reference overhangs.
+func alignedColumns(alignedQuery string) (int, int) {
+	start := strings.IndexFunc(alignedQuery, func(r rune) bool { return r != '-' })
+	if start < 0 {
+		return 0, 0
+	}
+	end := strings.LastIndexFunc(alignedQuery, func(r rune) bool { return r != '-' }) + 1
+	return start, end
+}
+
+// columnOp returns the CIGAR operation of one alignment column
+func columnOp(r, q byte) byte {
+	switch {
+	case r == '-':
+		return 'I'
+	case q == '-':
+		return 'D'
+	case upper(r) == upper(q):
+		return '='
+	default:
+		return 'X'
+	}
+}
+
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+// cigar encodes the aligned part of the query as an extended CIGAR string,
+// telling matches (=) from mismatches (X).
+func cigar(alignedReference, alignedQuery string) string {
+	start, end := alignedColumns(alignedQuery)
+
+	var b strings.Builder
+	for i := start; i < end; {
+		op := columnOp(alignedReference[i], alignedQuery[i])
+		length := 1
+		for i+length < end && columnOp(alignedReference[i+length], alignedQuery[i+length]) == op {
+			length++
+		}
+		b.WriteString(strconv.Itoa(length))
+		b.WriteByte(op)
+		i += length
+	}
+	return b.String()
+}
+
+// editOperations lists the substitutions, insertions and deletions in the
+// aligned part of the query. Consecutive insertions or deletions are merged
+// into a single operation.
+func editOperations(alignedReference, alignedQuery string, referenceStart, queryStart int) []EditOperation {
+	start, end := alignedColumns(alignedQuery)
+	referencePos := referenceStart + start
+	queryPos := queryStart
+
+	var operations []EditOperation
+	for i := start; i < end; i++ {
+		r, q := alignedReference[i], alignedQuery[i]
+		op := columnOp(r, q)
+
+		switch op {
+		case 'X':
+			operations = append(operations, EditOperation{
+				Op:             "X",
+				ReferencePos:   referencePos,
+				QueryPos:       queryPos,
+				ReferenceBases: string(r),
+				QueryBases:     string(q),
+			})
+		case 'I', 'D':
+			last := len(operations) - 1
+			if last < 0 || operations[last].Op != string(op) || !adjacent(operations[last], referencePos, queryPos) {
+				operations = append(operations, EditOperation{
+					Op:           string(op),
+					ReferencePos: referencePos,
+					QueryPos:     queryPos,
+				})
+				last++
+			}
+			if op == 'I' {
+				operations[last].QueryBases += string(q)
+			} else {
+				operations[last].ReferenceBases += string(r)
+			}
+		}
+
+		if r != '-' {
+			referencePos++
+		}
+		if q != '-' {
+			queryPos++
+		}
+	}
+	return operations
+}
+
+// adjacent reports whether an indel continues right before the given position
+func adjacent(operation EditOperation, referencePos, queryPos int) bool {
+	return operation.ReferencePos+len(operation.ReferenceBases) == referencePos &&
+		operation.QueryPos+len(operation.QueryBases) == queryPos
+}
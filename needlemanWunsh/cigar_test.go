@@ -0,0 +1,68 @@
+package needleman_wunsh
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAlignCigar(t *testing.T) {
+	tests := []struct {
+		seq1           string
+		seq2           string
+		wantFrom       int
+		wantCigar      string
+		wantOperations []EditOperation
+	}{
+		{
+			seq1:      "GTCGACG",
+			seq2:      "GTCGACG",
+			wantFrom:  0,
+			wantCigar: "7=",
+		},
+		{
+			seq1:      "GATTACAA",
+			seq2:      "GTCGACG",
+			wantFrom:  0,
+			wantCigar: "3I2=2X",
+			wantOperations: []EditOperation{
+				{Op: "I", ReferencePos: 0, QueryPos: 0, QueryBases: "GTC"},
+				{Op: "X", ReferencePos: 2, QueryPos: 5, ReferenceBases: "T", QueryBases: "C"},
+				{Op: "X", ReferencePos: 3, QueryPos: 6, ReferenceBases: "T", QueryBases: "G"},
+			},
+		},
+		{
+			seq1:      "TTTTACGTACGTACGTGGGG",
+			seq2:      "ACGTACCTACGT",
+			wantFrom:  4,
+			wantCigar: "6=1X5=",
+			wantOperations: []EditOperation{
+				{Op: "X", ReferencePos: 10, QueryPos: 6, ReferenceBases: "G", QueryBases: "C"},
+			},
+		},
+		{
+			seq1:      "ACGTACGTACGTACGTACGT",
+			seq2:      "ACGTACGTAAAAACGTACGTACGT",
+			wantFrom:  0,
+			wantCigar: "8=4D12=",
+			wantOperations: []EditOperation{
+				{Op: "D", ReferencePos: 8, QueryPos: 8, ReferenceBases: "AAAA"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		alignment, err := Align(tt.seq1, tt.seq2)
+		if err != nil {
+			t.Errorf("Align(%v, %v) unexpected error %v", tt.seq1, tt.seq2, err)
+			continue
+		}
+		if alignment.From != tt.wantFrom || alignment.Cigar != tt.wantCigar {
+			t.Errorf("Align(%v, %v) = (%v, %v), want (%v, %v)",
+				tt.seq1, tt.seq2, alignment.From, alignment.Cigar, tt.wantFrom, tt.wantCigar)
+		}
+		if !reflect.DeepEqual(alignment.Operations, tt.wantOperations) {
+			t.Errorf("Align(%v, %v) operations = %+v, want %+v",
+				tt.seq1, tt.seq2, alignment.Operations, tt.wantOperations)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package needleman_wunsh
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+)
+
+// AlignGlocal aligns the whole query against part of the reference, the
+// semi-global alignment of a fragment: gaps before and after the query on
+// the reference are free, so From and To are where the query maps instead
+// of the ends of penalized terminal gaps. Unlike Align, the sequences are
+// never swapped, and WithBand is refused.
+func AlignGlocal(referenceSequence, query string, opts ...Option) (Alignment, error) {
+	return AlignGlocalContext(context.Background(), referenceSequence, query, opts...)
+}
+
+// AlignGlocalContext is AlignGlocal, giving up with the error of ctx once it
+// is done
+func AlignGlocalContext(ctx context.Context, referenceSequence, query string, opts ...Option) (Alignment, error) {
+	if len(referenceSequence) == 0 {
+		return Alignment{}, errors.New("empty reference sequence")
+	}
+	if len(query) == 0 {
+		return Alignment{}, errors.New("empty query sequence")
+	}
+
+	cfg := newConfig(opts)
+	cfg.ctx = ctx
+	cfg.glocal = true
+	if cfg.band != nil {
+		return Alignment{}, errors.New("glocal alignments do not use bands")
+	}
+	if err := cfg.scoring.Validate(); err != nil {
+		return Alignment{}, err
+	}
+
+	// The query runs down the rows, so that the free first row and the best
+	// cell of the last one are where it starts and ends on the reference
+	traceResult, err := alignMatrix(query, referenceSequence, cfg)
+	if err != nil {
+		return Alignment{}, err
+	}
+	alignedReference, alignedQuery := traceResult.alignedQuery, traceResult.alignedReference
+
+	// The alignment starts from the first cell, the columns before the query
+	// being the reference overhang
+	start, end := alignedColumns(alignedQuery)
+	from := residues(alignedReference[:start])
+	to := from + residues(alignedReference[start:end])
+	coverage := float64(to-from) * 100 / float64(len(referenceSequence))
+
+	return Alignment{
+		From:             from,
+		To:               to,
+		Coverage:         math.Round(coverage*100) / 100,
+		AlignedReference: alignedReference,
+		AlignedQuery:     alignedQuery,
+		Cigar:            cigar(alignedReference, alignedQuery),
+		Operations:       editOperations(alignedReference, alignedQuery, 0, 0),
+	}, nil
+}
+
+// residues counts the residues of an aligned sequence
+func residues(aligned string) int {
+	return len(aligned) - strings.Count(aligned, "-")
+}
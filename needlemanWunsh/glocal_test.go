@@ -0,0 +1,138 @@
+package needleman_wunsh
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAlignGlocal(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	reference := randomSequence(r, 300)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantFrom  int
+		wantTo    int
+		wantCigar string
+	}{
+		{name: "exact", query: reference[120:180], wantFrom: 120, wantTo: 180, wantCigar: "60="},
+		{name: "prefix", query: reference[:50], wantFrom: 0, wantTo: 50, wantCigar: "50="},
+		{name: "suffix", query: reference[250:], wantFrom: 250, wantTo: 300, wantCigar: "50="},
+		{
+			name:      "deletion",
+			query:     reference[100:140] + reference[143:180],
+			wantFrom:  100,
+			wantTo:    180,
+			wantCigar: "40=3D37=",
+		},
+		{
+			name:      "overhang",
+			query:     "TTTTTTTT" + reference[:40],
+			wantFrom:  0,
+			wantTo:    40,
+			wantCigar: "8I40=",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := AlignGlocal(reference, tt.query)
+		if err != nil {
+			t.Errorf("AlignGlocal(%v) unexpected error %v", tt.name, err)
+			continue
+		}
+		if got.From != tt.wantFrom || got.To != tt.wantTo || got.Cigar != tt.wantCigar {
+			t.Errorf("AlignGlocal(%v) = %v, %v, %v, want %v, %v, %v",
+				tt.name, got.From, got.To, got.Cigar, tt.wantFrom, tt.wantTo, tt.wantCigar)
+		}
+		if strings.ReplaceAll(got.AlignedReference, "-", "") != reference ||
+			strings.ReplaceAll(got.AlignedQuery, "-", "") != tt.query {
+			t.Errorf("AlignGlocal(%v) aligned %v and %v, not the whole sequences", tt.name, got.AlignedReference, got.AlignedQuery)
+		}
+	}
+}
+
+// glocalScore returns the best semi-global score of query against reference
+func glocalScore(reference, query string, p ScoringParams) int {
+	inf := math.MinInt32 / 2
+	m, n := len(query)+1, len(reference)+1
+	v, e := make([]int, n), make([]int, n)
+	for j := range e {
+		e[j] = inf
+	}
+	for i := 1; i < m; i++ {
+		diagonal := v[0]
+		v[0] = -p.GapOpen - (i-1)*p.GapExtension
+		left, h := v[0], inf
+		for j := 1; j < n; j++ {
+			score := p.Mismatch
+			if query[i-1] == reference[j-1] {
+				score = p.Match
+			}
+			h = max(h-p.GapExtension, left-p.GapOpen)
+			e[j] = max(e[j]-p.GapExtension, v[j]-p.GapOpen)
+			diagonal, v[j] = v[j], max(diagonal+score, e[j], h)
+			left = v[j]
+		}
+	}
+	return slices.Max(v[1:])
+}
+
+// alignmentScore scores an alignment, leaving out the reference overhangs
+func alignmentScore(alignedReference, alignedQuery string, p ScoringParams) int {
+	start, end := alignedColumns(alignedQuery)
+	score := 0
+	for i := start; i < end; i++ {
+		switch op := columnOp(alignedReference[i], alignedQuery[i]); op {
+		case '=':
+			score += p.Match
+		case 'X':
+			score += p.Mismatch
+		default:
+			score -= p.GapExtension
+			if i == start || columnOp(alignedReference[i-1], alignedQuery[i-1]) != op {
+				score -= p.GapOpen - p.GapExtension
+			}
+		}
+	}
+	return score
+}
+
+func TestAlignGlocalOptimal(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	p := DefaultScoringParams()
+	for k := 0; k < 60; k++ {
+		reference := randomSequence(r, 20+r.Intn(300))
+		start := r.Intn(len(reference))
+		end := start + 1 + r.Intn(len(reference)-start)
+		query := mutate(r, reference[start:end], r.Intn(6))
+		if k%4 == 0 || query == "" {
+			query = randomSequence(r, 1+r.Intn(50))
+		}
+
+		want, err := AlignGlocal(reference, query, WithLinearSpaceThreshold(0))
+		if err != nil {
+			t.Fatalf("AlignGlocal(%v, %v) unexpected error %v", reference, query, err)
+		}
+		if got, best := alignmentScore(want.AlignedReference, want.AlignedQuery, p), glocalScore(reference, query, p); got != best {
+			t.Errorf("AlignGlocal(%v, %v) scores %v, want %v", reference, query, got, best)
+		}
+
+		for _, threshold := range []int{1, 256} {
+			got, err := AlignGlocal(reference, query, WithLinearSpaceThreshold(threshold))
+			if err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("AlignGlocal(%v, %v) with threshold %v = %+v, %v, want %+v", reference, query, threshold, got, err, want)
+			}
+		}
+	}
+}
+
+func TestAlignGlocalBand(t *testing.T) {
+	if _, err := AlignGlocal("ACGTACGT", "GTAC", WithBand(BandParams{})); err == nil {
+		t.Errorf("AlignGlocal() with a band returned no error")
+	}
+}
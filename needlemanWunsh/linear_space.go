@@ -0,0 +1,67 @@
+package needleman_wunsh
+
+import "math"
+
+// DefaultLinearSpaceThreshold is the matrix size, in cells, above which
+// alignments are computed in linear space. The traceback takes a byte per
+// cell, so whole-matrix alignments use up to 128 MiB.
+const DefaultLinearSpaceThreshold = 1 << 27
+
+// alignLinearSpace performs the same alignment as process and traceBack
+// without keeping the traceback of the whole matrix. A first pass finds the
+// best cell, then the traceback is divided and conquered over the rows: the
+// scores of the middle row are computed, the lower half is traced back from
+// them, then the upper half from the row above it, down to blocks that fit
+// the threshold.
+//
+// Each block is scored from the exact row above it, so the result is the
+// whole-matrix one, ties included. Memory grows with the query length and the
+// logarithm of the reference length, at the cost of about log2(cells /
+// threshold) / 2 extra passes over the matrix.
+func alignLinearSpace(rowString, columnString string, cfg config) (traceBackResult, error) {
+	v, g := firstRow(len(columnString) + 1)
+	best := processResult{score: math.Inf(-1)}
+	if err := fill(rowString, columnString, 1, len(rowString), v, g, nil, cfg, &best); err != nil {
+		return traceBackResult{}, err
+	}
+
+	alignment := tail(rowString, columnString, best.maxi, best.maxj)
+	v, g = firstRow(best.maxj + 1)
+	i, j, _, err := traceRows(rowString, columnString, 0, best.maxi, v, g, best.maxj, diagonal, cfg, &alignment)
+	if err != nil {
+		return traceBackResult{}, err
+	}
+	return finish(alignment, i, j), nil
+}
+
+// traceRows walks the alignment back from cell (last, j) until it starts or
+// reaches row first, v and g holding the scores of row first. The columns
+// right of j are never reached, so they are left out.
+func traceRows(rowString, columnString string, first, last int, v, g []float64, j int, state byte, cfg config, alignment *reversedAlignment) (int, int, byte, error) {
+	n := j + 1
+	if last-first <= 1 || (last-first+1)*n <= cfg.linearSpaceThreshold {
+		trace := make([]byte, (last-first+1)*n)
+		initBoundaries(trace, first, last, n)
+		if err := fill(rowString, columnString, first+1, last, clone(v[:n]), clone(g[:n]), trace, cfg, nil); err != nil {
+			return 0, 0, stop, err
+		}
+		i, j, state := walk(rowString, columnString, denseTrace(trace, first, n), first, last, j, state, alignment)
+		return i, j, state, nil
+	}
+
+	middle := (first + last) / 2
+	vMiddle, gMiddle := clone(v[:n]), clone(g[:n])
+	if err := fill(rowString, columnString, first+1, middle, vMiddle, gMiddle, nil, cfg, nil); err != nil {
+		return 0, 0, stop, err
+	}
+
+	i, j, state, err := traceRows(rowString, columnString, middle, last, vMiddle, gMiddle, j, state, cfg, alignment)
+	if err != nil || state == stop {
+		return i, j, state, err
+	}
+	return traceRows(rowString, columnString, first, middle, v, g, j, state, cfg, alignment)
+}
+
+func clone(scores []float64) []float64 {
+	return append([]float64(nil), scores...)
+}
@@ -0,0 +1,79 @@
+package needleman_wunsh
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// mutate applies random substitutions, insertions and deletions to sequence
+func mutate(r *rand.Rand, sequence string, edits int) string {
+	mutated := []byte(sequence)
+	for ; edits > 0 && len(mutated) > 1; edits-- {
+		position := r.Intn(len(mutated))
+		switch r.Intn(3) {
+		case 0:
+			mutated[position] = "ACGT"[r.Intn(4)]
+		case 1:
+			insertion := []byte(randomSequence(r, 1+r.Intn(8)))
+			mutated = append(mutated[:position], append(insertion, mutated[position:]...)...)
+		default:
+			end := min(position+1+r.Intn(8), len(mutated))
+			mutated = append(mutated[:position], mutated[end:]...)
+		}
+	}
+	return string(mutated)
+}
+
+func TestAlignLinearSpace(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	type testCase struct {
+		seq1 string
+		seq2 string
+		opts []Option
+	}
+	var tests []testCase
+	for k := 0; k < 40; k++ {
+		reference := randomSequence(r, 20+r.Intn(200))
+		start := r.Intn(len(reference) / 2)
+		end := start + 1 + r.Intn(len(reference)-start)
+		tests = append(tests, testCase{seq1: reference, seq2: mutate(r, reference[start:end], r.Intn(10))})
+		tests = append(tests, testCase{seq1: reference, seq2: randomSequence(r, 1+r.Intn(len(reference)))})
+	}
+	tests = append(tests,
+		testCase{seq1: "GATTACAA", seq2: "GTCGACG"},
+		testCase{seq1: "AACGATATTGCU", seq2: "AACGTGCU", opts: []Option{WithScoring(ScoringParams{Match: 5, Mismatch: -4, GapOpen: 10, GapExtension: 1})}},
+		testCase{seq1: "ACGTACGTACGTACGTACGT", seq2: "ACGTACGTAAAAACGTACGTACGT", opts: []Option{WithScoring(ScoringParams{Match: 1, Mismatch: -1, GapOpen: 0, GapExtension: 0})}},
+	)
+
+	for _, threshold := range []int{1, 64, 1024} {
+		for _, tt := range tests {
+			want, err := Align(tt.seq1, tt.seq2, append(tt.opts, WithLinearSpaceThreshold(0))...)
+			if err != nil {
+				t.Fatalf("Align(%v, %v) unexpected error %v", tt.seq1, tt.seq2, err)
+			}
+			got, err := Align(tt.seq1, tt.seq2, append(tt.opts, WithLinearSpaceThreshold(threshold))...)
+			if err != nil {
+				t.Errorf("Align(%v, %v) in linear space unexpected error %v", tt.seq1, tt.seq2, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Align(%v, %v) with threshold %v = %+v, want %+v", tt.seq1, tt.seq2, threshold, got, want)
+			}
+		}
+	}
+}
+
+func TestAlignContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, threshold := range []int{0, 64} {
+		_, err := AlignContext(ctx, "ACGTACGTACGTACGT", "ACGTTACGT", WithLinearSpaceThreshold(threshold))
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("AlignContext() with threshold %v error = %v, want %v", threshold, err, context.Canceled)
+		}
+	}
+}
@@ -1,91 +1,215 @@
 package needleman_wunsh
 
 import (
+	"context"
 	"errors"
 	"math"
 )
 
 // GlobalAlignment performs sequence alignment using Needleman-Gotoh algorithm
 // Returns map_init, map_end, and coverage percentage
-func NeedlemanWunsch(referenceSequence, sequenceToAlign string) (int, int, float64, error) {
+func NeedlemanWunsch(referenceSequence, sequenceToAlign string, opts ...Option) (int, int, float64, error) {
+	return NeedlemanWunschContext(context.Background(), referenceSequence, sequenceToAlign, opts...)
+}
+
+// NeedlemanWunschContext is NeedlemanWunsch, giving up with the error of ctx
+// once it is done
+func NeedlemanWunschContext(ctx context.Context, referenceSequence, sequenceToAlign string, opts ...Option) (int, int, float64, error) {
+	alignment, err := AlignContext(ctx, referenceSequence, sequenceToAlign, opts...)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return alignment.From, alignment.To, alignment.Coverage, nil
+}
+
+// Alignment is the global alignment of a query against a reference. When the
+// reference given is shorter than the query the two are swapped, so the
+// reference is always the longer sequence.
+type Alignment struct {
+	From             int     // map_init
+	To               int     // map_end
+	Coverage         float64 // percentage of the reference covered
+	AlignedReference string
+	AlignedQuery     string
+	Cigar            string          // starting at From, with =/X for matches/mismatches
+	Operations       []EditOperation // edits turning the reference into the query
+}
+
+// Align performs the same alignment as NeedlemanWunsch and also returns the
+// aligned sequences, as gapped strings, a CIGAR string and edit operations.
+func Align(referenceSequence, sequenceToAlign string, opts ...Option) (Alignment, error) {
+	return AlignContext(context.Background(), referenceSequence, sequenceToAlign, opts...)
+}
+
+// AlignContext is Align, giving up with the error of ctx once it is done. The
+// matrices are filled a row at a time, checking ctx before every row.
+func AlignContext(ctx context.Context, referenceSequence, sequenceToAlign string, opts ...Option) (Alignment, error) {
 	if len(referenceSequence) == 0 {
-		return 0, 0, 0, errors.New("empty reference sequence")
+		return Alignment{}, errors.New("empty reference sequence")
 	}
 	if len(sequenceToAlign) == 0 {
-		return 0, 0, 0, errors.New("empty query sequence")
+		return Alignment{}, errors.New("empty query sequence")
 	}
 
-	// Constants for scoring
-	const (
-		Match     = 2  // match score
-		MissMatch = -3 // mismatch penalty
-		Gap       = 5  // gap opening penalty
-		Ge        = 2  // gap extension penalty
-	)
+	cfg := newConfig(opts)
+	cfg.ctx = ctx
+	if err := cfg.scoring.Validate(); err != nil {
+		return Alignment{}, err
+	}
+	if cfg.band != nil {
+		if err := cfg.band.Validate(); err != nil {
+			return Alignment{}, err
+		}
+	}
 
 	// Swap sequences if reference is shorter
 	if len(referenceSequence) < len(sequenceToAlign) {
 		referenceSequence, sequenceToAlign = sequenceToAlign, referenceSequence
 	}
 
-	seqRefLength := len(referenceSequence) + 1
-	seqAlignLength := len(sequenceToAlign) + 1
-	sizeArray := seqRefLength * seqAlignLength
+	traceResult, err := alignMatrix(referenceSequence, sequenceToAlign, cfg)
+	if err != nil {
+		return Alignment{}, err
+	}
+
+	coverage := float64(traceResult.to-traceResult.from) * 100 / float64(len(referenceSequence))
 
-	pointers := make([]int8, sizeArray)
-	lengths := make([]int8, sizeArray)
+	return Alignment{
+		From:             traceResult.from,
+		To:               traceResult.to,
+		Coverage:         math.Round(coverage*100) / 100,
+		AlignedReference: traceResult.alignedReference,
+		AlignedQuery:     traceResult.alignedQuery,
+		Cigar:            cigar(traceResult.alignedReference, traceResult.alignedQuery),
+		Operations: editOperations(traceResult.alignedReference, traceResult.alignedQuery,
+			traceResult.referenceStart, traceResult.queryStart),
+	}, nil
+}
 
-	result := process(referenceSequence, sequenceToAlign, pointers, lengths, Match, MissMatch, Gap, Ge)
-	traceResult := traceBack(referenceSequence, sequenceToAlign, result.maxi, result.maxj, pointers, lengths)
+// alignMatrix aligns columnString against rowString within the band of cfg
+// if it holds the alignment, otherwise with the whole matrix or in linear
+// space past the threshold of cfg
+func alignMatrix(rowString, columnString string, cfg config) (traceBackResult, error) {
+	seqRefLength := len(rowString) + 1
+	seqAlignLength := len(columnString) + 1
+	sizeArray := seqRefLength * seqAlignLength
 
-	coverage := float64(traceResult.to-traceResult.from) * 100 / float64(len(referenceSequence))
-	
-	return traceResult.from, traceResult.to, math.Round(coverage*100)/100, nil
+	traceResult, banded, err := alignBanded(rowString, columnString, cfg)
+	if err != nil {
+		return traceBackResult{}, err
+	}
+	switch {
+	case banded:
+		// The alignment stayed inside the band
+	case cfg.linearSpaceThreshold > 0 && sizeArray > cfg.linearSpaceThreshold:
+		if traceResult, err = alignLinearSpace(rowString, columnString, cfg); err != nil {
+			return traceBackResult{}, err
+		}
+	default:
+		trace := make([]byte, sizeArray)
+
+		result, err := process(rowString, columnString, trace, cfg)
+		if err != nil {
+			return traceBackResult{}, err
+		}
+		traceResult = traceBack(rowString, columnString, result.maxi, result.maxj, trace)
+	}
+	return traceResult, nil
 }
 
+// Traceback of a cell, packed in one byte. The low bits hold the move that
+// gives the cell its best score, the flags whether the vertical and the
+// horizontal gaps ending on the cell extend the gap of the previous cell.
+// Following the flags instead of storing gap lengths lets the traceback walk
+// back gaps of any length.
+const (
+	stop       byte = 0 // the alignment starts here
+	left       byte = 1 // gap in the reference
+	diagonal   byte = 2 // match or mismatch
+	up         byte = 3 // gap in the query
+	moveMask   byte = 3
+	upGapExt   byte = 1 << 2
+	leftGapExt byte = 1 << 3
+)
+
 type processResult struct {
 	maxi, maxj int
-	score      float64
+	score      float64 // best score, reached at maxi, maxj
 }
 
-func process(rowString, columnString string, pointers, lengths []int8, M, Ms, G, Ge int) processResult {
+func process(rowString, columnString string, trace []byte, cfg config) (processResult, error) {
 	m := len(rowString) + 1
 	n := len(columnString) + 1
 
-	// Initialize boundaries
-	for i, k := 1, n; i < m; i, k = i+1, k+n {
-		pointers[k] = 3
-		lengths[k] = int8(i)
+	initBoundaries(trace, 0, m-1, n)
+	v, g := firstRow(n)
+	result := processResult{score: math.Inf(-1)}
+	err := fill(rowString, columnString, 1, m-1, v, g, trace, cfg, &result)
+	return result, err
+}
+
+// initBoundaries sets the moves of the boundary cells of rows first through
+// last, each one a single gap back to the origin. trace holds these rows
+// only, starting with row first.
+func initBoundaries(trace []byte, first, last, n int) {
+	for i, k := max(first, 1), (max(first, 1)-first)*n; i <= last; i, k = i+1, k+n {
+		trace[k] = up
+		if i > 1 {
+			trace[k] |= upGapExt
+		}
 	}
-	for j := 1; j < n; j++ {
-		pointers[j] = 1
-		lengths[j] = int8(j)
+	if first == 0 {
+		for j := 1; j < n; j++ {
+			trace[j] = left
+			if j > 1 {
+				trace[j] |= leftGapExt
+			}
+		}
 	}
+}
 
+// firstRow returns the scores of row 0 of the matrices
+func firstRow(n int) ([]float64, []float64) {
 	v := make([]float64, n)
-	vDiagonal := 0.0
-	f := math.Inf(-1)
-	h := math.Inf(-1)
 	g := make([]float64, n)
 	for i := range g {
 		g[i] = math.Inf(-1)
 	}
+	return v, g
+}
 
-	lengthOfHorizontalGap := 0
-	lengthOfVerticalGap := make([]int, n)
+// fill computes rows first through last of the matrices, with v and g
+// holding the scores of the row above on entry and those of row last on
+// return. When trace is not nil the moves are stored in it, starting with
+// the row above first, and when best is not nil it keeps the best cell, of
+// the last row of the matrix only for glocal alignments. It stops with the
+// error of the context of cfg once it is done.
+func fill(rowString, columnString string, first, last int, v, g []float64, trace []byte, cfg config, best *processResult) error {
+	M, Ms, G, Ge := cfg.scoring.Match, cfg.scoring.Mismatch, cfg.scoring.GapOpen, cfg.scoring.GapExtension
+	n := len(v)
 
-	maximumScore := math.Inf(-1)
-	var maxi, maxj int
+	vDiagonal := 0.0
+	f := math.Inf(-1)
+	h := math.Inf(-1)
 
 	// Fill matrices
-	for i := 1; i < m; i++ {
+	for i := first; i <= last; i++ {
+		if err := cfg.ctx.Err(); err != nil {
+			return err
+		}
+
+		if cfg.glocal {
+			vDiagonal = v[0]
+		}
 		v[0] = float64(-G - (i-1)*Ge)
-		k := i * n
+		k := (i - first + 1) * n
 
 		for j := 1; j < n; j++ {
-			l := k + j
+			var cell byte
 			similarityScore := Ms
-			if rowString[i-1] == columnString[j-1] {
+			if cfg.matrix != nil {
+				similarityScore = cfg.matrix.Score(rowString[i-1], columnString[j-1])
+			} else if rowString[i-1] == columnString[j-1] {
 				similarityScore = M
 			}
 
@@ -94,142 +218,155 @@ func process(rowString, columnString string, pointers, lengths []int8, M, Ms, G,
 			// From left
 			if h-float64(Ge) >= v[j-1]-float64(G) {
 				h -= float64(Ge)
-				lengthOfHorizontalGap++
+				cell |= leftGapExt
 			} else {
 				h = v[j-1] - float64(G)
-				lengthOfHorizontalGap = 1
 			}
 
 			// From above
 			if g[j]-float64(Ge) >= v[j]-float64(G) {
 				g[j] = g[j] - float64(Ge)
-				lengthOfVerticalGap[j]++
+				cell |= upGapExt
 			} else {
 				g[j] = v[j] - float64(G)
-				lengthOfVerticalGap[j] = 1
 			}
 
 			vDiagonal = v[j]
 			v[j] = math.Max(f, math.Max(g[j], h))
 
-			if v[j] > maximumScore {
-				maximumScore = v[j]
-				maxi = i
-				maxj = j
+			if best != nil && v[j] > best.score && (!cfg.glocal || i == len(rowString)) {
+				best.score = v[j]
+				best.maxi = i
+				best.maxj = j
 			}
 
-			// Set pointer and length
-			if v[j] == f {
-				pointers[l] = 2
-			} else if v[j] == g[j] && v[j] != 0 {
-				pointers[l] = 3
-				lengths[l] = int8(lengthOfVerticalGap[j])
-			} else if v[j] == h && v[j] != 0 {
-				pointers[l] = 1
-				lengths[l] = int8(lengthOfHorizontalGap)
+			// Set move
+			if trace != nil {
+				if v[j] == f {
+					cell |= diagonal
+				} else if v[j] == g[j] && (v[j] != 0 || cfg.glocal) {
+					cell |= up
+				} else if v[j] == h && (v[j] != 0 || cfg.glocal) {
+					cell |= left
+				}
+				trace[k+j] = cell
 			}
 		}
 
 		h = math.Inf(-1)
 		vDiagonal = 0
-		lengthOfHorizontalGap = 0
 	}
-
-	return processResult{maxi: maxi, maxj: maxj, score: v[n-1]}
+	return nil
 }
 
 type traceBackResult struct {
-	from, to int
+	from, to                       int
+	alignedReference, alignedQuery string
+	referenceStart, queryStart     int // where the traceback stopped
 }
 
-func traceBack(als1, als2 string, rowa, cola int, pointers, lengths []int8) traceBackResult {
-	maxLength := len(als1) + len(als2)
-	reversed1 := make([]rune, maxLength)
-	reversed2 := make([]rune, maxLength)
+// reversedAlignment is an alignment built from its last column backwards
+type reversedAlignment struct {
+	reference, query []rune
+}
 
-	var len1, len2 int
-	i := rowa
-	j := cola
-	n := len(als2) + 1
-	row := i * n
+func (r *reversedAlignment) push(reference, query rune) {
+	r.reference = append(r.reference, reference)
+	r.query = append(r.query, query)
+}
+
+func traceBack(als1, als2 string, rowa, cola int, trace []byte) traceBackResult {
+	alignment := tail(als1, als2, rowa, cola)
+	i, j, _ := walk(als1, als2, denseTrace(trace, 0, len(als2)+1), 0, rowa, cola, diagonal, &alignment)
+	return finish(alignment, i, j)
+}
+
+// tail starts the alignment with the residues after cell (i, j), aligned
+// without gaps but for the overhang of the longer remainder.
+func tail(als1, als2 string, i, j int) reversedAlignment {
+	maxLength := len(als1) + len(als2)
+	alignment := reversedAlignment{
+		reference: make([]rune, 0, maxLength),
+		query:     make([]rune, 0, maxLength),
+	}
 
 	a := len(als1) - 1
 	b := len(als2) - 1
 
 	if a-i > b-j {
 		for ; a-i > b-j; a-- {
-			reversed1[len1] = rune(als1[a])
-			reversed2[len2] = '-'
-			len1++
-			len2++
+			alignment.push(rune(als1[a]), '-')
 		}
 		for ; b > j-1; a, b = a-1, b-1 {
-			reversed1[len1] = rune(als1[a])
-			reversed2[len2] = rune(als2[b])
-			len1++
-			len2++
+			alignment.push(rune(als1[a]), rune(als2[b]))
 		}
 	} else {
 		for ; b-j > a-i; b-- {
-			reversed1[len1] = '-'
-			reversed2[len2] = rune(als2[b])
-			len1++
-			len2++
+			alignment.push('-', rune(als2[b]))
 		}
 		for ; a > i-1; a, b = a-1, b-1 {
-			reversed1[len1] = rune(als1[a])
-			reversed2[len2] = rune(als2[b])
-			len1++
-			len2++
+			alignment.push(rune(als1[a]), rune(als2[b]))
 		}
 	}
+	return alignment
+}
 
-	stillGoing := true
-	for stillGoing {
-		l := row + j
-		switch pointers[l] {
-		case 3:
-			for k := 0; k < int(lengths[l]); k++ {
-				i--
-				reversed1[len1] = rune(als1[i])
-				reversed2[len2] = '-'
-				len1++
-				len2++
-				row -= n
-			}
-			if lengths[l] <= 0 {
-				row -= n
+// denseTrace returns the moves of trace, which holds n columns of the rows
+// from first on
+func denseTrace(trace []byte, first, n int) func(i, j int) byte {
+	return func(i, j int) byte {
+		return trace[(i-first)*n+j]
+	}
+}
+
+// walk traces the alignment back from cell (i, j), in the given state, until
+// it starts or reaches row first. cells returns the moves of the rows from
+// first on, and a walk reaching row first stops there, unless it is row 0.
+// Returns the cell and state it stopped at, the state being stop once the
+// alignment starts.
+func walk(als1, als2 string, cells func(i, j int) byte, first, i, j int, state byte, alignment *reversedAlignment) (int, int, byte) {
+	// Walk back one column at a time, following the gap flags while inside a
+	// gap and the cell moves otherwise
+	for first == 0 || i > first {
+		cell := cells(i, j)
+		if state == diagonal {
+			state = cell & moveMask
+		}
+
+		switch state {
+		case up:
+			i--
+			alignment.push(rune(als1[i]), '-')
+			if cell&upGapExt == 0 {
+				state = diagonal
 			}
-		case 2:
+		case diagonal:
 			i--
 			j--
-			reversed1[len1] = rune(als1[i])
-			reversed2[len2] = rune(als2[j])
-			len1++
-			len2++
-			row -= n
-		case 1:
-			if lengths[l] < 0 && row <= 0 {
-				stillGoing = false
-			} else if lengths[l] < 0 {
-				lengths[l] = 1
-			}
-			for k := 0; k < int(lengths[l]); k++ {
-				j--
-				reversed1[len1] = '-'
-				reversed2[len2] = rune(als2[j])
-				len1++
-				len2++
+			alignment.push(rune(als1[i]), rune(als2[j]))
+		case left:
+			j--
+			alignment.push('-', rune(als2[j]))
+			if cell&leftGapExt == 0 {
+				state = diagonal
 			}
-		case 0:
-			stillGoing = false
+		default:
+			return i, j, stop
 		}
 	}
+	return i, j, state
+}
 
-	alignedSeq2 := reverseString(reversed2[:len2])
+// finish turns the alignment, traced back to cell (i, j), into the result
+func finish(alignment reversedAlignment, i, j int) traceBackResult {
+	alignedSeq2 := reverseString(alignment.query)
 	return traceBackResult{
-		from: getFrom(alignedSeq2),
-		to:   getTo(string(reversed1[:len1]), alignedSeq2) +1,
+		from:             getFrom(alignedSeq2),
+		to:               getTo(string(alignment.reference), alignedSeq2) + 1,
+		alignedReference: reverseString(alignment.reference),
+		alignedQuery:     alignedSeq2,
+		referenceStart:   i,
+		queryStart:       j,
 	}
 }
 
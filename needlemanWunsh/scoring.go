@@ -0,0 +1,105 @@
+package needleman_wunsh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	substitution_matrix "github.com/vsdbmv2/worker-go/substitutionMatrix"
+)
+
+// ScoringParams holds the Needleman-Gotoh scoring scheme. Gap penalties are
+// subtracted from the score, so they are given as non-negative values.
+type ScoringParams struct {
+	Match        int `json:"match"`        // match score
+	Mismatch     int `json:"mismatch"`     // mismatch score
+	GapOpen      int `json:"gapOpen"`      // gap opening penalty
+	GapExtension int `json:"gapExtension"` // gap extension penalty
+}
+
+// DefaultScoringParams returns the scoring scheme used when none is given
+func DefaultScoringParams() ScoringParams {
+	return ScoringParams{
+		Match:        2,
+		Mismatch:     -3,
+		GapOpen:      5,
+		GapExtension: 2,
+	}
+}
+
+// UnmarshalJSON fills the fields missing from data with their default values
+func (p *ScoringParams) UnmarshalJSON(data []byte) error {
+	type plain ScoringParams
+	params := plain(DefaultScoringParams())
+	if err := json.Unmarshal(data, &params); err != nil {
+		return err
+	}
+	*p = ScoringParams(params)
+	return nil
+}
+
+// Validate reports whether the scoring scheme can be used for an alignment
+func (p ScoringParams) Validate() error {
+	if p.GapOpen < 0 || p.GapExtension < 0 {
+		return errors.New("gap penalties must not be negative")
+	}
+	return nil
+}
+
+type config struct {
+	scoring              ScoringParams
+	matrix               *substitution_matrix.Matrix
+	linearSpaceThreshold int
+	band                 *BandParams     // nil aligns with the whole matrix
+	glocal               bool            // set by AlignGlocalContext
+	ctx                  context.Context // set by AlignContext, never nil
+}
+
+// Option customizes a NeedlemanWunsch alignment
+type Option func(*config)
+
+// WithScoring aligns using the given scoring scheme instead of the default one
+func WithScoring(params ScoringParams) Option {
+	return func(c *config) {
+		c.scoring = params
+	}
+}
+
+// WithMatrix scores residue pairs with a substitution matrix instead of the
+// match and mismatch scores, keeping the gap penalties of the scoring scheme.
+func WithMatrix(matrix *substitution_matrix.Matrix) Option {
+	return func(c *config) {
+		c.matrix = matrix
+	}
+}
+
+// WithLinearSpaceThreshold aligns in linear space when the alignment matrix
+// has more than cells cells, instead of DefaultLinearSpaceThreshold. Zero or
+// less always aligns with the whole matrix.
+func WithLinearSpaceThreshold(cells int) Option {
+	return func(c *config) {
+		c.linearSpaceThreshold = cells
+	}
+}
+
+// maxScore returns the highest score of a pair of residues
+func (c config) maxScore() int {
+	if c.matrix == nil {
+		return c.scoring.Match
+	}
+	best := c.matrix.Score(c.matrix.Alphabet[0], c.matrix.Alphabet[0])
+	for i := 0; i < len(c.matrix.Alphabet); i++ {
+		for j := 0; j < len(c.matrix.Alphabet); j++ {
+			best = max(best, c.matrix.Score(c.matrix.Alphabet[i], c.matrix.Alphabet[j]))
+		}
+	}
+	return best
+}
+
+func newConfig(opts []Option) config {
+	c := config{scoring: DefaultScoringParams(), linearSpaceThreshold: DefaultLinearSpaceThreshold, ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
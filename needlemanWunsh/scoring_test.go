@@ -0,0 +1,105 @@
+package needleman_wunsh
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	substitution_matrix "github.com/vsdbmv2/worker-go/substitutionMatrix"
+)
+
+func TestNeedlemanWunschWithScoring(t *testing.T) {
+	tests := []struct {
+		seq1         string
+		seq2         string
+		params       ScoringParams
+		wantInit     int
+		wantEnd      int
+		wantCoverage float64
+		wantErr      bool
+	}{
+		{
+			seq1:         "AACGATATTGCU",
+			seq2:         "AACGTGCU",
+			params:       DefaultScoringParams(),
+			wantInit:     0,
+			wantEnd:      8,
+			wantCoverage: 66.67,
+		},
+		{
+			seq1:         "AACGATATTGCU",
+			seq2:         "AACGTGCU",
+			params:       ScoringParams{Match: 5, Mismatch: -4, GapOpen: 10, GapExtension: 1},
+			wantInit:     0,
+			wantEnd:      12,
+			wantCoverage: 100,
+		},
+		{
+			seq1:    "GATTACAA",
+			seq2:    "GTCGACG",
+			params:  ScoringParams{Match: 2, Mismatch: -3, GapOpen: -5, GapExtension: 2},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		init, end, coverage, err := NeedlemanWunsch(tt.seq1, tt.seq2, WithScoring(tt.params))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NeedlemanWunsch(%v, %v, %+v) error = %v, wantErr %v",
+				tt.seq1, tt.seq2, tt.params, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if init != tt.wantInit || end != tt.wantEnd || coverage != tt.wantCoverage {
+			t.Errorf("NeedlemanWunsch(%v, %v, %+v) = (%v, %v, %v), want (%v, %v, %v)",
+				tt.seq1, tt.seq2, tt.params, init, end, coverage, tt.wantInit, tt.wantEnd, tt.wantCoverage)
+		}
+	}
+}
+
+func TestScoringParamsUnmarshalJSON(t *testing.T) {
+	var params ScoringParams
+	if err := json.Unmarshal([]byte(`{"match": 5, "gapOpen": 10}`), &params); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	want := ScoringParams{Match: 5, Mismatch: -3, GapOpen: 10, GapExtension: 2}
+	if params != want {
+		t.Errorf("json.Unmarshal() = %+v, want %+v", params, want)
+	}
+}
+
+func TestNeedlemanWunschWithMatrix(t *testing.T) {
+	matrix, err := substitution_matrix.Lookup("NUC.4.4")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+
+	// NUC.4.4 scores A/C/G/T pairs like a 5/-4 match/mismatch scheme, and
+	// lowercase residues like uppercase ones.
+	params := ScoringParams{Match: 5, Mismatch: -4, GapOpen: 5, GapExtension: 2}
+	tests := []struct {
+		seq1 string
+		seq2 string
+	}{
+		{seq1: "AACGATATTGCT", seq2: "AACGTGCT"},
+		{seq1: "GATTACAA", seq2: "GTCGACG"},
+		{seq1: "CCCCTTTTAAAAGGGG", seq2: "TTTAAGAAGG"},
+	}
+
+	for _, tt := range tests {
+		wantInit, wantEnd, wantCoverage, _ := NeedlemanWunsch(tt.seq1, tt.seq2, WithScoring(params))
+		init, end, coverage, err := NeedlemanWunsch(strings.ToLower(tt.seq1), tt.seq2,
+			WithScoring(params), WithMatrix(matrix))
+		if err != nil {
+			t.Errorf("NeedlemanWunsch(%v, %v) unexpected error %v", tt.seq1, tt.seq2, err)
+			continue
+		}
+		if init != wantInit || end != wantEnd || coverage != wantCoverage {
+			t.Errorf("NeedlemanWunsch(%v, %v) = (%v, %v, %v), want (%v, %v, %v)",
+				tt.seq1, tt.seq2, init, end, coverage, wantInit, wantEnd, wantCoverage)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package needleman_wunsh
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func randomSequence(r *rand.Rand, length int) string {
+	sequence := make([]byte, length)
+	for i := range sequence {
+		sequence[i] = "ACGT"[r.Intn(4)]
+	}
+	return string(sequence)
+}
+
+// Gaps longer than 127 used to wrap around in the traceback
+func TestAlignLongIndels(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	left, deleted, right := randomSequence(r, 1500), randomSequence(r, 1200), randomSequence(r, 1500)
+	prefix, before, inserted, after, suffix := randomSequence(r, 500), randomSequence(r, 1500),
+		randomSequence(r, 1200), randomSequence(r, 1500), randomSequence(r, 1000)
+
+	tests := []struct {
+		name      string
+		seq1      string
+		seq2      string
+		wantFrom  int
+		wantTo    int
+		wantCigar string
+		wantOp    string
+	}{
+		{
+			name:      "deletion",
+			seq1:      left + deleted + right,
+			seq2:      left + right,
+			wantFrom:  0,
+			wantTo:    4200,
+			wantCigar: "1499=1200D1501=",
+			wantOp:    "D",
+		},
+		{
+			name:      "insertion",
+			seq1:      prefix + before + after + suffix,
+			seq2:      before + inserted + after,
+			wantFrom:  500,
+			wantTo:    4700,
+			wantCigar: "1500=1200I1500=",
+			wantOp:    "I",
+		},
+	}
+
+	for _, tt := range tests {
+		alignment, err := Align(tt.seq1, tt.seq2)
+		if err != nil {
+			t.Errorf("Align(%v) unexpected error %v", tt.name, err)
+			continue
+		}
+		if alignment.From != tt.wantFrom || alignment.To != tt.wantTo || alignment.Cigar != tt.wantCigar {
+			t.Errorf("Align(%v) = (%v, %v, %v), want (%v, %v, %v)", tt.name,
+				alignment.From, alignment.To, alignment.Cigar, tt.wantFrom, tt.wantTo, tt.wantCigar)
+		}
+		if len(alignment.Operations) != 1 || alignment.Operations[0].Op != tt.wantOp {
+			t.Errorf("Align(%v) operations = %+v, want a single %v", tt.name, alignment.Operations, tt.wantOp)
+			continue
+		}
+		if bases := alignment.Operations[0].ReferenceBases + alignment.Operations[0].QueryBases; len(bases) != 1200 {
+			t.Errorf("Align(%v) indel length = %v, want 1200", tt.name, len(bases))
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/vsdbmv2/worker-go/processor"
+)
+
+// job is a queued or running Work, with the function aborting it
+type job struct {
+	work   Work
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
+// errShuttingDown is why the jobs left when the grace period of a shutdown
+// runs out are canceled
+var errShuttingDown = errors.New("worker shutting down")
+
+// pool runs jobs on a fixed number of workers fed by a bounded queue. Submit
+// never blocks, so that the read loop keeps handling cancel-work events: jobs
+// that do not fit in the queue wait in a backlog, and the server is held back
+// by get-work requests instead.
+type pool struct {
+	size    int
+	jobs    chan *job
+	results chan<- Result
+
+	queued  atomic.Int64
+	running atomic.Int64
+	workers sync.WaitGroup
+	feeder  sync.WaitGroup
+
+	mu         sync.Mutex
+	active     map[string][]*job // queued and running jobs, by identifier
+	backlog    []*job            // jobs waiting for room in the queue, oldest first
+	more       *sync.Cond        // signaled on new backlog and on draining
+	draining   bool
+	unfinished []string // identifiers of the jobs handed back on shutdown
+}
+
+// newPool starts size workers sending finished jobs on results, with room for
+// queueSize jobs waiting on top of the ones being processed.
+func newPool(size, queueSize int, results chan<- Result) *pool {
+	p := &pool{
+		size:    size,
+		jobs:    make(chan *job, queueSize),
+		results: results,
+		active:  make(map[string][]*job),
+	}
+	p.more = sync.NewCond(&p.mu)
+	p.workers.Add(size)
+	for i := 0; i < size; i++ {
+		go p.work()
+	}
+	p.feeder.Add(1)
+	go p.feed()
+	return p
+}
+
+func (p *pool) work() {
+	defer p.workers.Done()
+	for j := range p.jobs {
+		p.running.Add(1)
+		p.queued.Add(-1)
+		if !processWork(j.ctx, j.work, p.results) {
+			p.mu.Lock()
+			p.unfinished = append(p.unfinished, j.work.Identifier)
+			p.mu.Unlock()
+		}
+		p.running.Add(-1)
+		p.untrack(j)
+	}
+}
+
+// submit queues a job, holding it in the backlog while the queue is full,
+// and reports whether it was queued before stop was done and the pool
+// started draining.
+func (p *pool) submit(stop context.Context, work Work) bool {
+	if stop.Err() != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	j := &job{work: work, ctx: ctx, cancel: cancel}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.draining {
+		cancel(nil)
+		return false
+	}
+	p.active[work.Identifier] = append(p.active[work.Identifier], j)
+	p.queued.Add(1)
+
+	// Jobs overtake none of the backlog
+	if len(p.backlog) == 0 {
+		select {
+		case p.jobs <- j:
+			return true
+		default:
+		}
+	}
+	p.backlog = append(p.backlog, j)
+	p.more.Signal()
+	return true
+}
+
+// feed moves the backlog to the queue as it makes room, until the pool is
+// draining and the backlog is empty
+func (p *pool) feed() {
+	defer p.feeder.Done()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		for len(p.backlog) == 0 && !p.draining {
+			p.more.Wait()
+		}
+		if len(p.backlog) == 0 {
+			return
+		}
+
+		// The job stays in the backlog until queued, for submit to queue
+		// after it
+		j := p.backlog[0]
+		p.mu.Unlock()
+		p.jobs <- j
+		p.mu.Lock()
+		p.backlog = p.backlog[1:]
+	}
+}
+
+// untrack forgets a finished job and releases its context
+func (p *pool) untrack(j *job) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	jobs := p.active[j.work.Identifier]
+	for i, other := range jobs {
+		if other == j {
+			jobs = append(jobs[:i], jobs[i+1:]...)
+			break
+		}
+	}
+	if len(jobs) == 0 {
+		delete(p.active, j.work.Identifier)
+	} else {
+		p.active[j.work.Identifier] = jobs
+	}
+	j.cancel(nil)
+}
+
+// cancel aborts the queued and running jobs with the given identifier, and
+// returns how many there were. Each of them still reports a result, failing
+// with a canceled error unless it finished first.
+func (p *pool) cancel(identifier string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, j := range p.active[identifier] {
+		j.cancel(nil)
+	}
+	return len(p.active[identifier])
+}
+
+// drain stops the pool once the jobs already submitted are done, waiting
+// for them at most grace before canceling the ones left with
+// errShuttingDown, and returns the identifiers of those, sorted, to hand back
+// to the server. Every other job reports its result before drain returns.
+func (p *pool) drain(grace time.Duration) []string {
+	p.mu.Lock()
+	p.draining = true
+	p.more.Signal()
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.feeder.Wait()
+		close(p.jobs)
+		p.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(grace):
+	}
+
+	p.mu.Lock()
+	for _, jobs := range p.active {
+		for _, j := range jobs {
+			j.cancel(errShuttingDown)
+		}
+	}
+	p.mu.Unlock()
+
+	<-done
+	sort.Strings(p.unfinished)
+	return p.unfinished
+}
+
+// queueDepth is the number of jobs waiting for a free worker
+func (p *pool) queueDepth() int {
+	return int(p.queued.Load())
+}
+
+// inFlight is the number of jobs currently being processed
+func (p *pool) inFlight() int {
+	return int(p.running.Load())
+}
+
+// available is the number of additional jobs the pool can start right away
+func (p *pool) available() int {
+	return max(p.size-p.queueDepth()-p.inFlight(), 0)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/vsdbmv2/worker-go/processor"
+)
+
+// collect gathers the results sent on a channel until it is closed, handing
+// them over on the returned one
+func collect(results <-chan Result) <-chan []Result {
+	collected := make(chan []Result, 1)
+	go func() {
+		var all []Result
+		for result := range results {
+			all = append(all, result)
+		}
+		collected <- all
+	}()
+	return collected
+}
+
+// identifiers returns the identifiers of results, in order
+func identifiers(results []Result) []string {
+	var ids []string
+	for _, result := range results {
+		ids = append(ids, result.Identifier)
+	}
+	return ids
+}
+
+func TestPoolBacklog(t *testing.T) {
+	results := make(chan Result)
+	collected := collect(results)
+	workers := newPool(1, 1, results)
+
+	// The worker and the queue are taken, so the last works overflow into
+	// the backlog, which keeps them in order
+	want := []string{"running", "a", "b", "c", "d"}
+	for _, id := range want {
+		work := Work{Type: GlobalMapping, Identifier: id, Sequence1: "ACGT", Sequence2: SequencePayloadOf("ACGT")}
+		if id == "running" {
+			work.Type = "test-block"
+		}
+		if !workers.submit(context.Background(), work) {
+			t.Fatalf("submit(%v) failed", id)
+		}
+	}
+	if got := workers.queueDepth() + workers.inFlight(); got != len(want) {
+		t.Errorf("%d jobs queued or running, want %d", got, len(want))
+	}
+	if got := workers.available(); got != 0 {
+		t.Errorf("available() = %d, want 0", got)
+	}
+
+	workers.cancel("running")
+	if unfinished := workers.drain(time.Minute); unfinished != nil {
+		t.Errorf("drain() = %v, want nothing unfinished", unfinished)
+	}
+	close(results)
+	if got := identifiers(<-collected); !reflect.DeepEqual(got, want) {
+		t.Errorf("results %v, want %v", got, want)
+	}
+
+	if workers.submit(context.Background(), Work{Identifier: "late"}) {
+		t.Errorf("submit() succeeded on a drained pool")
+	}
+}
+
+func TestPoolCancel(t *testing.T) {
+	results := make(chan Result)
+	collected := collect(results)
+	workers := newPool(1, 1, results)
+
+	workers.submit(context.Background(), Work{Type: "test-block", Identifier: "running"})
+	workers.submit(context.Background(), Work{Type: GlobalMapping, Identifier: "queued", Sequence1: "ACGT", Sequence2: SequencePayloadOf("ACGT")})
+
+	// The queued job fails as canceled without ever running
+	if n := workers.cancel("queued"); n != 1 {
+		t.Errorf("cancel(queued) = %d, want 1", n)
+	}
+	if n := workers.cancel("missing"); n != 0 {
+		t.Errorf("cancel(missing) = %d, want 0", n)
+	}
+	workers.cancel("running")
+	workers.drain(time.Minute)
+	close(results)
+
+	got := <-collected
+	if ids := identifiers(got); !reflect.DeepEqual(ids, []string{"running", "queued"}) {
+		t.Fatalf("results %v, want running and queued", ids)
+	}
+	for _, result := range got {
+		if result.Error == nil || result.Error.Code != ErrCanceled {
+			t.Errorf("result %v error = %+v, want code %v", result.Identifier, result.Error, ErrCanceled)
+		}
+	}
+	if n := workers.cancel("queued"); n != 0 {
+		t.Errorf("cancel(queued) after it finished = %d, want 0", n)
+	}
+}
+
+func TestPoolDrainGrace(t *testing.T) {
+	results := make(chan Result)
+	collected := collect(results)
+	workers := newPool(1, 1, results)
+
+	for _, id := range []string{"done", "running", "queued", "backlog", "backlog"} {
+		work := Work{Type: "test-block", Identifier: id}
+		if id == "done" {
+			work = Work{Type: GlobalMapping, Identifier: id, Sequence1: "ACGT", Sequence2: SequencePayloadOf("ACGT")}
+		}
+		workers.submit(context.Background(), work)
+	}
+
+	// Every job left when the grace runs out is handed back once, and
+	// reports no result
+	start := time.Now()
+	unfinished := workers.drain(50 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("drain() took %v, want about its grace", elapsed)
+	}
+	if want := []string{"backlog", "backlog", "queued", "running"}; !reflect.DeepEqual(unfinished, want) {
+		t.Errorf("drain() = %v, want %v", unfinished, want)
+	}
+	close(results)
+	if got := identifiers(<-collected); !reflect.DeepEqual(got, []string{"done"}) {
+		t.Errorf("results %v, want done only", got)
+	}
+}
@@ -0,0 +1,11 @@
+// Package builtin links every processor shipped with the worker. A package
+// adding a job type is blank imported here, and the worker and its offline
+// commands import this package instead of each processor.
+package builtin
+
+import (
+	_ "github.com/vsdbmv2/worker-go/processor/epitopeMapping"
+	_ "github.com/vsdbmv2/worker-go/processor/globalMapping"
+	_ "github.com/vsdbmv2/worker-go/processor/glocalMapping"
+	_ "github.com/vsdbmv2/worker-go/processor/localMapping"
+)
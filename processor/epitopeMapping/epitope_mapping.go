@@ -0,0 +1,99 @@
+// Package epitope_mapping registers the processor of epitope mappings, which
+// find the epitopes of sequence2 in sequence1, or in its six frames.
+package epitope_mapping
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/vsdbmv2/worker-go/epitopeMap"
+	. "github.com/vsdbmv2/worker-go/processor"
+	"github.com/vsdbmv2/worker-go/translation"
+)
+
+func init() {
+	Register(EpitopeMapping, ProcessorFunc(processEpitopeMapping))
+}
+
+func processEpitopeMapping(ctx context.Context, work Work) (Result, error) {
+	epitopes, ok := work.Sequence2.Epitopes()
+	if !ok {
+		return Result{}, NewJobError(work, ErrInvalidPayload, errors.New("expected string or array of epitopes"))
+	}
+	if err := DistanceMetric(work.DistanceMetric).Validate(); err != nil {
+		return Result{}, NewJobError(work, ErrInvalidPayload, err)
+	}
+	if work.BatchID != "" && work.BatchSize <= 0 {
+		return Result{}, NewJobError(work, ErrInvalidPayload, errors.New("batchSize must be positive with a batchId"))
+	}
+
+	var rules *MatchRules
+	if work.Ambiguity != "" && AmbiguityMode(work.Ambiguity) != AmbiguityExact {
+		alphabet := Protein
+		if work.Alphabet != "" {
+			alphabet = Alphabet(work.Alphabet)
+		}
+		var err error
+		if rules, err = NewMatchRules(alphabet, AmbiguityMode(work.Ambiguity)); err != nil {
+			return Result{}, NewJobError(work, ErrInvalidPayload, err)
+		}
+	}
+
+	mapPeptide := func(peptide string) ([]EpitopeMap, error) {
+		if rules != nil {
+			return MapEpitopesWithRules(ctx, peptide, epitopes, work.MaxDistance, DistanceMetric(work.DistanceMetric), rules)
+		}
+		if work.MaxDistance != 0 {
+			return MapEpitopesApprox(ctx, peptide, epitopes, work.MaxDistance, DistanceMetric(work.DistanceMetric))
+		}
+		return MatcherFor(epitopes).MatchContext(ctx, peptide)
+	}
+
+	var mapped []EpitopeMap
+	var err error
+	if work.GeneticCode != 0 {
+		code, codeErr := translation.Lookup(work.GeneticCode)
+		if codeErr != nil {
+			return Result{}, NewJobError(work, ErrInvalidPayload, codeErr)
+		}
+		mapped, err = MapFrames(work.Sequence1, code, mapPeptide)
+	} else {
+		mapped, err = mapPeptide(work.Sequence1)
+	}
+	if err != nil && ctx.Err() == nil {
+		return Result{}, NewJobError(work, ErrInvalidPayload, err)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Type:              work.Type,
+		Organism:          work.Organism,
+		Identifier:        work.Identifier,
+		IDSequence:        work.ID1,
+		IDSequenceSubtype: work.ID2,
+		IDSubtype:         work.IDSubtype,
+		EpitopeMaps:       sentEpitopes(mapped, epitopes, work.Sequence2.Sent()),
+	}, nil
+}
+
+// sentEpitopes gives maps the epitope sequences as the server sent them,
+// epitopes being their normalized form they were matched with
+func sentEpitopes(maps []EpitopeMap, epitopes, sent []Epitope) []EpitopeMap {
+	type key struct {
+		id       int
+		sequence string
+	}
+	sequences := make(map[key]string, len(epitopes))
+	for i, epitope := range epitopes {
+		k := key{epitope.ID, epitope.LinearSequence}
+		if _, ok := sequences[k]; !ok {
+			sequences[k] = sent[i].LinearSequence
+		}
+	}
+	for i, m := range maps {
+		maps[i].LinearSequence = sequences[key{m.IDEpitope, m.LinearSequence}]
+	}
+	return maps
+}
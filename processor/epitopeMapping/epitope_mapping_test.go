@@ -0,0 +1,69 @@
+package epitope_mapping
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	. "github.com/vsdbmv2/worker-go/epitopeMap"
+	. "github.com/vsdbmv2/worker-go/processor"
+)
+
+func TestEpitopeMapping(t *testing.T) {
+	epitopes := EpitopePayloadOf([]Epitope{{ID: 1, LinearSequence: "siinFEKL"}, {ID: 2, LinearSequence: "GILGFVFTL"}})
+	tests := []struct {
+		name     string
+		work     Work
+		want     []EpitopeMap
+		wantCode string
+	}{
+		{
+			name: "exact",
+			work: Work{Type: EpitopeMapping, Sequence1: "MSIINFEKLGILGFVFTL", Sequence2: epitopes},
+			want: []EpitopeMap{
+				{LinearSequence: "siinFEKL", InitPos: 1, IDEpitope: 1},
+				{LinearSequence: "GILGFVFTL", InitPos: 9, IDEpitope: 2},
+			},
+		},
+		{
+			name: "approximate",
+			work: Work{Type: EpitopeMapping, Sequence1: "MSIINFEKL", Sequence2: EpitopePayloadOf([]Epitope{{LinearSequence: "SIIAFEKL"}}),
+				MaxDistance: 1},
+			want: []EpitopeMap{{LinearSequence: "SIIAFEKL", InitPos: 1, Distance: 1,
+				Mutations: []Mutation{{Position: 3, EpitopeResidue: "A", SequenceResidue: "N"}}}},
+		},
+		{
+			name: "sequence",
+			work: Work{Type: EpitopeMapping, Sequence1: "MSIINFEKL", Sequence2: SequencePayloadOf("SIINFEKL")},
+			want: []EpitopeMap{{LinearSequence: "SIINFEKL", InitPos: 1}},
+		},
+		{
+			name:     "unknown metric",
+			work:     Work{Type: EpitopeMapping, Sequence1: "MSIINFEKL", Sequence2: epitopes, DistanceMetric: "levenshtein"},
+			wantCode: ErrInvalidPayload,
+		},
+		{
+			name:     "batch without size",
+			work:     Work{Type: EpitopeMapping, Sequence1: "MSIINFEKL", Sequence2: epitopes, BatchID: "batch"},
+			wantCode: ErrInvalidPayload,
+		},
+		{
+			name:     "unknown genetic code",
+			work:     Work{Type: EpitopeMapping, Sequence1: "ATGAGC", Sequence2: epitopes, GeneticCode: 99},
+			wantCode: ErrInvalidPayload,
+		},
+	}
+
+	for _, tt := range tests {
+		got := Process(context.Background(), tt.work)
+		if tt.wantCode != "" {
+			if got.Error == nil || got.Error.Code != tt.wantCode {
+				t.Errorf("Process(%v) error = %+v, want code %v", tt.name, got.Error, tt.wantCode)
+			}
+			continue
+		}
+		if got.Error != nil || !reflect.DeepEqual(got.EpitopeMaps, tt.want) {
+			t.Errorf("Process(%v) = %+v, %+v, want %+v", tt.name, got.EpitopeMaps, got.Error, tt.want)
+		}
+	}
+}
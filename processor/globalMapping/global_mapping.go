@@ -0,0 +1,88 @@
+// Package global_mapping registers the processor of global mappings, which
+// align sequence2 against sequence1 with Needleman-Gotoh, and lets the other
+// Needleman-Gotoh mappings build their results the same way.
+package global_mapping
+
+import (
+	"context"
+	"errors"
+
+	needleman_wunsh "github.com/vsdbmv2/worker-go/needlemanWunsh"
+	. "github.com/vsdbmv2/worker-go/processor"
+	substitution_matrix "github.com/vsdbmv2/worker-go/substitutionMatrix"
+)
+
+func init() {
+	Register(GlobalMapping, ProcessorFunc(func(ctx context.Context, work Work) (Result, error) {
+		return Map(ctx, work, "global mapping", needleman_wunsh.AlignContext)
+	}))
+}
+
+// linearSpaceThreshold is the matrix size, in cells, above which mappings
+// are aligned in linear space
+var linearSpaceThreshold = needleman_wunsh.DefaultLinearSpaceThreshold
+
+// SetLinearSpaceThreshold aligns mappings in linear space past cells cells
+// instead of needleman_wunsh.DefaultLinearSpaceThreshold
+func SetLinearSpaceThreshold(cells int) {
+	linearSpaceThreshold = cells
+}
+
+// LinearSpaceThreshold returns the matrix size, in cells, above which
+// mappings are aligned in linear space
+func LinearSpaceThreshold() int {
+	return linearSpaceThreshold
+}
+
+// AlignFunc aligns a query against a reference, as needleman_wunsh.AlignContext
+type AlignFunc func(ctx context.Context, referenceSequence, query string, opts ...needleman_wunsh.Option) (needleman_wunsh.Alignment, error)
+
+// Map maps sequence2 on sequence1 with align, the Needleman-Gotoh alignment
+// of mapping, a description of the work type for its errors
+func Map(ctx context.Context, work Work, mapping string, align AlignFunc) (Result, error) {
+	sequence, ok := work.Sequence2.Single()
+	if !ok {
+		return Result{}, NewJobError(work, ErrInvalidPayload, errors.New("string expected in "+mapping))
+	}
+
+	opts, err := alignmentOptions(work)
+	if err != nil {
+		return Result{}, err
+	}
+
+	alignment, err := align(ctx, work.Sequence1, sequence, opts...)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Type:        work.Type,
+		Organism:    work.Organism,
+		MapInit:     alignment.From,
+		MapEnd:      alignment.To,
+		Identifier:  work.Identifier,
+		CoveragePct: alignment.Coverage,
+		IDSequence:  work.ID2,
+		Cigar:       alignment.Cigar,
+		Operations:  alignment.Operations,
+	}, nil
+}
+
+// alignmentOptions returns the Needleman-Gotoh options asked for by a work
+func alignmentOptions(work Work) ([]needleman_wunsh.Option, error) {
+	opts := []needleman_wunsh.Option{needleman_wunsh.WithLinearSpaceThreshold(linearSpaceThreshold)}
+	if work.Scoring != nil {
+		opts = append(opts, needleman_wunsh.WithScoring(*work.Scoring))
+	}
+	if work.Band != nil {
+		opts = append(opts, needleman_wunsh.WithBand(*work.Band))
+	}
+	if work.Matrix != "" {
+		matrix, err := substitution_matrix.Lookup(work.Matrix)
+		if err != nil {
+			return nil, NewJobError(work, ErrInvalidPayload, err)
+		}
+		opts = append(opts, needleman_wunsh.WithMatrix(matrix))
+	}
+	return opts, nil
+}
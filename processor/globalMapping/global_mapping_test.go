@@ -0,0 +1,54 @@
+package global_mapping
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/vsdbmv2/worker-go/epitopeMap"
+	needleman_wunsh "github.com/vsdbmv2/worker-go/needlemanWunsh"
+	. "github.com/vsdbmv2/worker-go/processor"
+)
+
+func TestGlobalMapping(t *testing.T) {
+	reference, query := "ACGTTGCAACGTAGCTAGCATCGA", "TTGCAACGAAGCTAG"
+	alignment, err := needleman_wunsh.Align(reference, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	work := Work{Type: GlobalMapping, Identifier: "job", ID2: 7, Sequence1: reference, Sequence2: SequencePayloadOf(query)}
+
+	got := Process(context.Background(), work)
+	if got.Error != nil || got.MapInit != alignment.From || got.MapEnd != alignment.To ||
+		got.Cigar != alignment.Cigar || got.IDSequence != 7 {
+		t.Errorf("Process(%+v) = %+v, want the mapping of %+v", work, got, alignment)
+	}
+
+	SetLinearSpaceThreshold(1)
+	defer SetLinearSpaceThreshold(needleman_wunsh.DefaultLinearSpaceThreshold)
+	if linear := Process(context.Background(), work); linear.Cigar != got.Cigar {
+		t.Errorf("Process(%+v) in linear space = %+v, want %+v", work, linear, got)
+	}
+}
+
+func TestGlobalMappingInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		work Work
+	}{
+		{
+			name: "epitopes",
+			work: Work{Type: GlobalMapping, Sequence1: "ACGT", Sequence2: EpitopePayloadOf([]Epitope{{LinearSequence: "ACGT"}})},
+		},
+		{
+			name: "unknown matrix",
+			work: Work{Type: GlobalMapping, Sequence1: "ACGT", Sequence2: SequencePayloadOf("ACGT"), Matrix: "BLOSUM0"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := Process(context.Background(), tt.work)
+		if got.Error == nil || got.Error.Code != ErrInvalidPayload {
+			t.Errorf("Process(%v) error = %+v, want code %v", tt.name, got.Error, ErrInvalidPayload)
+		}
+	}
+}
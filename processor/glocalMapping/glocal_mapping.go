@@ -0,0 +1,23 @@
+// Package glocal_mapping registers the processor of glocal mappings, which
+// align the whole of sequence2 against part of sequence1.
+package glocal_mapping
+
+import (
+	"context"
+	"errors"
+
+	needleman_wunsh "github.com/vsdbmv2/worker-go/needlemanWunsh"
+	. "github.com/vsdbmv2/worker-go/processor"
+	global_mapping "github.com/vsdbmv2/worker-go/processor/globalMapping"
+)
+
+func init() {
+	Register(GlocalMapping, ProcessorFunc(processGlocalMapping))
+}
+
+func processGlocalMapping(ctx context.Context, work Work) (Result, error) {
+	if work.Band != nil {
+		return Result{}, NewJobError(work, ErrInvalidPayload, errors.New("band is only used by global mapping"))
+	}
+	return global_mapping.Map(ctx, work, "glocal mapping", needleman_wunsh.AlignGlocalContext)
+}
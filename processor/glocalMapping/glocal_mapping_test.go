@@ -0,0 +1,50 @@
+package glocal_mapping
+
+import (
+	"context"
+	"testing"
+
+	needleman_wunsh "github.com/vsdbmv2/worker-go/needlemanWunsh"
+	. "github.com/vsdbmv2/worker-go/processor"
+)
+
+func TestGlocalMapping(t *testing.T) {
+	reference := "GATTACAGGCTTACCGTAGGATCCA"
+	tests := []struct {
+		name     string
+		work     Work
+		wantFrom int
+		wantTo   int
+		wantCode string
+	}{
+		{
+			name:     "fragment",
+			work:     Work{Type: GlocalMapping, Sequence1: reference, Sequence2: SequencePayloadOf(reference[8:17])},
+			wantFrom: 8,
+			wantTo:   17,
+		},
+		{
+			name:     "band",
+			work:     Work{Type: GlocalMapping, Sequence1: reference, Sequence2: SequencePayloadOf(reference[8:17]), Band: &needleman_wunsh.BandParams{}},
+			wantCode: ErrInvalidPayload,
+		},
+		{
+			name:     "no sequence",
+			work:     Work{Type: GlocalMapping, Sequence1: reference},
+			wantCode: ErrInvalidPayload,
+		},
+	}
+
+	for _, tt := range tests {
+		got := Process(context.Background(), tt.work)
+		if tt.wantCode != "" {
+			if got.Error == nil || got.Error.Code != tt.wantCode {
+				t.Errorf("Process(%v) error = %+v, want code %v", tt.name, got.Error, tt.wantCode)
+			}
+			continue
+		}
+		if got.Error != nil || got.MapInit != tt.wantFrom || got.MapEnd != tt.wantTo {
+			t.Errorf("Process(%v) = %+v, want %v to %v", tt.name, got, tt.wantFrom, tt.wantTo)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package processor
+
+import "fmt"
+
+// Error codes reported in work-failed events
+const (
+	ErrInvalidPayload      = "invalid-payload"
+	ErrAlignment           = "alignment-error"
+	ErrPanic               = "panic"
+	ErrUnsupportedWorkType = "unsupported-work-type"
+	ErrTimeout             = "timeout"          // the job ran past its timeout
+	ErrCanceled            = "canceled"         // the job was aborted by a cancel-work event or a shutdown
+	ErrIncompleteBatch     = "incomplete-batch" // an epitope batch summarized before all its jobs were done
+)
+
+// JobError describes why a single job could not produce a result
+type JobError struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Identifier string `json:"identifier"`
+}
+
+func (e *JobError) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", e.Identifier, e.Message, e.Code)
+}
+
+// NewJobError wraps err as a JobError with the given code for work
+func NewJobError(work Work, code string, err error) *JobError {
+	return &JobError{
+		Code:       code,
+		Message:    err.Error(),
+		Identifier: work.Identifier,
+	}
+}
+
+// FailedResult builds the Result reported for a job that failed with err
+func FailedResult(work Work, err *JobError) Result {
+	return Result{
+		Type:       work.Type,
+		Organism:   work.Organism,
+		Identifier: work.Identifier,
+		Error:      err,
+	}
+}
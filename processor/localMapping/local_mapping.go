@@ -0,0 +1,56 @@
+// Package local_mapping registers the processor of local mappings, which
+// align sequence2 against sequence1 with Smith-Waterman.
+package local_mapping
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/vsdbmv2/worker-go/processor"
+	smith_waterman "github.com/vsdbmv2/worker-go/smithWaterman"
+	substitution_matrix "github.com/vsdbmv2/worker-go/substitutionMatrix"
+)
+
+func init() {
+	Register(LocalMapping, ProcessorFunc(processLocalMapping))
+}
+
+func processLocalMapping(ctx context.Context, work Work) (Result, error) {
+	sequence, ok := work.Sequence2.Single()
+	if !ok {
+		return Result{}, NewJobError(work, ErrInvalidPayload, errors.New("string expected in local mapping"))
+	}
+
+	var opts []smith_waterman.Option
+	if work.Matrix != "" {
+		matrix, err := substitution_matrix.Lookup(work.Matrix)
+		if err != nil {
+			return Result{}, NewJobError(work, ErrInvalidPayload, err)
+		}
+		opts = append(opts, smith_waterman.WithMatrix(matrix))
+	}
+
+	alignment, err := smith_waterman.SmithWatermanAlignmentContext(ctx, work.Sequence1, sequence, opts...)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Type:              work.Type,
+		Organism:          work.Organism,
+		Identifier:        work.Identifier,
+		AlignmentScore:    alignment.Score,
+		IDSequence:        work.ID1,
+		IDSequenceSubtype: work.ID2,
+		IDSubtype:         work.IDSubtype,
+		RefStart:          alignment.ReferenceStart,
+		RefEnd:            alignment.ReferenceEnd,
+		QueryStart:        alignment.QueryStart,
+		QueryEnd:          alignment.QueryEnd,
+		AlignedReference:  alignment.AlignedReference,
+		AlignedQuery:      alignment.AlignedQuery,
+		IdentityPct:       alignment.Identity,
+		SimilarityPct:     alignment.Similarity,
+		Gaps:              alignment.Gaps,
+	}, nil
+}
@@ -0,0 +1,61 @@
+package local_mapping
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/vsdbmv2/worker-go/processor"
+	smith_waterman "github.com/vsdbmv2/worker-go/smithWaterman"
+)
+
+func TestLocalMapping(t *testing.T) {
+	reference, query := "TTTTTGATTACAGGCTTACCTTTTT", "CCGATTACAGGCTAACCGG"
+	alignment, err := smith_waterman.SmithWatermanAlignment(reference, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		work     Work
+		want     Result
+		wantCode string
+	}{
+		{
+			name: "sequences",
+			work: Work{Type: LocalMapping, Identifier: "job", ID1: 1, ID2: 2, IDSubtype: 3,
+				Sequence1: reference, Sequence2: SequencePayloadOf(query)},
+			want: Result{Type: LocalMapping, Identifier: "job", AlignmentScore: alignment.Score,
+				IDSequence: 1, IDSequenceSubtype: 2, IDSubtype: 3,
+				RefStart: alignment.ReferenceStart, RefEnd: alignment.ReferenceEnd,
+				QueryStart: alignment.QueryStart, QueryEnd: alignment.QueryEnd,
+				AlignedReference: alignment.AlignedReference, AlignedQuery: alignment.AlignedQuery,
+				IdentityPct: alignment.Identity, SimilarityPct: alignment.Similarity, Gaps: alignment.Gaps},
+		},
+		{
+			name:     "unknown matrix",
+			work:     Work{Type: LocalMapping, Sequence1: reference, Sequence2: SequencePayloadOf(query), Matrix: "BLOSUM0"},
+			wantCode: ErrInvalidPayload,
+		},
+		{
+			name:     "no sequence",
+			work:     Work{Type: LocalMapping, Sequence1: reference},
+			wantCode: ErrInvalidPayload,
+		},
+	}
+
+	for _, tt := range tests {
+		got := Process(context.Background(), tt.work)
+		if tt.wantCode != "" {
+			if got.Error == nil || got.Error.Code != tt.wantCode {
+				t.Errorf("Process(%v) error = %+v, want code %v", tt.name, got.Error, tt.wantCode)
+			}
+			continue
+		}
+		if got.AlignedReference != tt.want.AlignedReference || got.AlignedQuery != tt.want.AlignedQuery ||
+			got.AlignmentScore != tt.want.AlignmentScore || got.RefStart != tt.want.RefStart || got.Error != nil ||
+			got.IDSequence != 1 || got.IDSequenceSubtype != 2 || got.IDSubtype != 3 {
+			t.Errorf("Process(%v) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
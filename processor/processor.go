@@ -0,0 +1,133 @@
+// Package processor holds the jobs a worker runs and the registry of the
+// processors that run them. A package adding a job type registers its
+// Processor from an init function and is linked into the worker with a
+// blank import in package builtin. Results the fields of Result do not fit
+// go in its Payload, which must be valid JSON.
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Processor runs the jobs of one work type, giving up with the error of ctx
+// once it is done. An error that is not a JobError is reported as an
+// alignment error.
+type Processor interface {
+	Process(ctx context.Context, work Work) (Result, error)
+}
+
+// ProcessorFunc adapts a function to the Processor interface
+type ProcessorFunc func(ctx context.Context, work Work) (Result, error)
+
+// Process calls f(ctx, work)
+func (f ProcessorFunc) Process(ctx context.Context, work Work) (Result, error) {
+	return f(ctx, work)
+}
+
+var registry = struct {
+	sync.RWMutex
+	byType map[WorkType]Processor
+}{byType: make(map[WorkType]Processor)}
+
+// Register makes p the processor of the jobs of workType. It panics if the
+// work type already has one.
+func Register(workType WorkType, p Processor) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.byType[workType]; ok {
+		panic(fmt.Sprintf("processor: work type %q registered twice", workType))
+	}
+	registry.byType[workType] = p
+}
+
+// Lookup returns the processor registered for workType
+func Lookup(workType WorkType) (Processor, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	p, ok := registry.byType[workType]
+	return p, ok
+}
+
+// WorkTypes returns the registered work types, sorted
+func WorkTypes() []WorkType {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	types := make([]WorkType, 0, len(registry.byType))
+	for workType := range registry.byType {
+		types = append(types, workType)
+	}
+	sort.Slice(types, func(a, b int) bool { return types[a] < types[b] })
+	return types
+}
+
+// Process runs work on the processor of its type, with its sequence files
+// loaded and its sequences normalized, until ctx is done or the timeout of
+// the work runs out. It always returns a Result: an unknown work type, an
+// error, a cancellation or a panic becomes a Result carrying a JobError
+// instead of taking the whole worker down.
+func Process(ctx context.Context, work Work) Result {
+	result, _ := ProcessLoaded(ctx, work)
+	return result
+}
+
+// ProcessLoaded is Process, also returning work with its sequence files
+// loaded, as it was before being normalized, so that callers need not read
+// them again. It is work itself when they were not loaded.
+func ProcessLoaded(ctx context.Context, work Work) (result Result, loaded Work) {
+	loaded = work
+	p, ok := Lookup(work.Type)
+	if !ok {
+		err := fmt.Errorf("unsupported work type %q", work.Type)
+		return FailedResult(work, NewJobError(work, ErrUnsupportedWorkType, err)), loaded
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered from panic in job %s: %v", work.Identifier, r)
+			result = FailedResult(work, &JobError{Code: ErrPanic, Message: fmt.Sprint(r), Identifier: work.Identifier})
+		}
+	}()
+
+	if work.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(work.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+
+	// A job canceled while queued is not started at all
+	err := ctx.Err()
+	if err == nil {
+		if loaded, err = LoadSequenceFiles(work); err != nil {
+			return FailedResult(work, NewJobError(work, ErrInvalidPayload, err)), work
+		}
+		result, err = p.Process(ctx, NormalizeWork(loaded))
+	}
+	// A payload that is not JSON would fail every attempt to send the result
+	if err == nil && result.Payload != nil && !json.Valid(result.Payload) {
+		err = errors.New("processor returned a payload that is not valid JSON")
+	}
+	if err != nil {
+		var jobErr *JobError
+		switch {
+		case errors.As(err, &jobErr):
+		case errors.Is(err, context.DeadlineExceeded):
+			jobErr = NewJobError(work, ErrTimeout, err)
+		case errors.Is(err, context.Canceled):
+			jobErr = NewJobError(work, ErrCanceled, err)
+		default:
+			jobErr = NewJobError(work, ErrAlignment, err)
+		}
+		return FailedResult(work, jobErr), loaded
+	}
+	return result, loaded
+}
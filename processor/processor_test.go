@@ -0,0 +1,133 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func init() {
+	Register("test-echo", ProcessorFunc(func(ctx context.Context, work Work) (Result, error) {
+		return Result{Type: work.Type, Identifier: work.Identifier, Cigar: work.Sequence1}, nil
+	}))
+	Register("test-error", ProcessorFunc(func(ctx context.Context, work Work) (Result, error) {
+		return Result{}, errors.New("no alignment")
+	}))
+	Register("test-invalid", ProcessorFunc(func(ctx context.Context, work Work) (Result, error) {
+		return Result{}, NewJobError(work, ErrInvalidPayload, errors.New("bad sequence"))
+	}))
+	Register("test-slow", ProcessorFunc(func(ctx context.Context, work Work) (Result, error) {
+		<-ctx.Done()
+		return Result{}, ctx.Err()
+	}))
+	Register("test-panic", ProcessorFunc(func(ctx context.Context, work Work) (Result, error) {
+		panic("index out of range")
+	}))
+	Register("test-payload", ProcessorFunc(func(ctx context.Context, work Work) (Result, error) {
+		return Result{Type: work.Type, Identifier: work.Identifier, Payload: json.RawMessage(`{"kmers":3}`)}, nil
+	}))
+	Register("test-bad-payload", ProcessorFunc(func(ctx context.Context, work Work) (Result, error) {
+		return Result{Type: work.Type, Identifier: work.Identifier, Payload: json.RawMessage(`{"kmers":`)}, nil
+	}))
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		workType WorkType
+		want     Result
+	}{
+		{
+			workType: "test-echo",
+			want:     Result{Type: "test-echo", Identifier: "job", Cigar: "ACGT"},
+		},
+		{
+			workType: "test-error",
+			want: Result{Type: "test-error", Identifier: "job",
+				Error: &JobError{Code: ErrAlignment, Message: "no alignment", Identifier: "job"}},
+		},
+		{
+			workType: "test-invalid",
+			want: Result{Type: "test-invalid", Identifier: "job",
+				Error: &JobError{Code: ErrInvalidPayload, Message: "bad sequence", Identifier: "job"}},
+		},
+		{
+			workType: "test-panic",
+			want: Result{Type: "test-panic", Identifier: "job",
+				Error: &JobError{Code: ErrPanic, Message: "index out of range", Identifier: "job"}},
+		},
+		{
+			workType: "test-payload",
+			want:     Result{Type: "test-payload", Identifier: "job", Payload: json.RawMessage(`{"kmers":3}`)},
+		},
+		{
+			workType: "test-bad-payload",
+			want: Result{Type: "test-bad-payload", Identifier: "job",
+				Error: &JobError{Code: ErrAlignment, Message: "processor returned a payload that is not valid JSON", Identifier: "job"}},
+		},
+		{
+			workType: "test-unknown",
+			want: Result{Type: "test-unknown", Identifier: "job",
+				Error: &JobError{Code: ErrUnsupportedWorkType, Message: `unsupported work type "test-unknown"`, Identifier: "job"}},
+		},
+	}
+
+	for _, tt := range tests {
+		got := Process(context.Background(), Work{Type: tt.workType, Identifier: "job", Sequence1: "acgt"})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Process(%v) = %+v, want %+v", tt.workType, got, tt.want)
+		}
+	}
+}
+
+func TestResultPayload(t *testing.T) {
+	result := Process(context.Background(), Work{Type: "test-payload", Identifier: "job"})
+	got, err := json.Marshal(result)
+	want := `{"organism":"","type":"test-payload","identifier":"job","payload":{"kmers":3}}`
+	if err != nil || string(got) != want {
+		t.Errorf("json.Marshal(%+v) = %s, %v, want %s", result, got, err, want)
+	}
+}
+
+func TestProcessCanceled(t *testing.T) {
+	got := Process(context.Background(), Work{Type: "test-slow", Identifier: "job", TimeoutMs: 10})
+	if got.Error == nil || got.Error.Code != ErrTimeout {
+		t.Errorf("Process(timeout) error = %+v, want code %v", got.Error, ErrTimeout)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	got = Process(ctx, Work{Type: "test-slow", Identifier: "job"})
+	if got.Error == nil || got.Error.Code != ErrCanceled {
+		t.Errorf("Process(canceled) error = %+v, want code %v", got.Error, ErrCanceled)
+	}
+
+	// Canceled before it starts, even a job that never checks ctx fails
+	got = Process(ctx, Work{Type: "test-echo", Identifier: "job"})
+	if got.Error == nil || got.Error.Code != ErrCanceled {
+		t.Errorf("Process(canceled before start) error = %+v, want code %v", got.Error, ErrCanceled)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	if _, ok := Lookup("test-echo"); !ok {
+		t.Errorf("Lookup(test-echo) not found")
+	}
+	if _, ok := Lookup("test-unknown"); ok {
+		t.Errorf("Lookup(test-unknown) found")
+	}
+
+	want := []WorkType{"test-bad-payload", "test-echo", "test-error", "test-invalid", "test-panic", "test-payload", "test-slow"}
+	if got := WorkTypes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("WorkTypes() = %v, want %v", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Register(test-echo) twice did not panic")
+		}
+	}()
+	Register("test-echo", ProcessorFunc(func(ctx context.Context, work Work) (Result, error) { return Result{}, nil }))
+}
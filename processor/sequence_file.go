@@ -0,0 +1,141 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+
+	. "github.com/vsdbmv2/worker-go/epitopeMap"
+	"github.com/vsdbmv2/worker-go/seqio"
+)
+
+// SequenceFile points at a FASTA, FASTQ or GenBank file, possibly gzipped, in
+// the data directory of the worker, so that large sequences need not be sent
+// in the work itself
+type SequenceFile struct {
+	Path   string `json:"path"`             // relative to the data directory
+	Record string `json:"record,omitempty"` // ID of the record to use, the first one or all the epitopes when empty
+}
+
+// dataDir is the directory sequence files are read from, none when empty
+var dataDir string
+
+// SetDataDir lets works read sequence files from dir. Files outside of it,
+// symbolic links included, are refused.
+func SetDataDir(dir string) {
+	dataDir = dir
+}
+
+// LoadSequenceFiles returns work with the sequences of its files read into
+// Sequence1 and Sequence2. For epitope mapping, every record of the
+// sequence2 file is an epitope unless one is chosen.
+func LoadSequenceFiles(work Work) (Work, error) {
+	if work.Sequence1File != nil {
+		records, err := work.Sequence1File.read(false)
+		if err != nil {
+			return work, err
+		}
+		work.Sequence1 = records[0].Sequence
+	}
+	if work.Sequence2File != nil {
+		all := work.Type == EpitopeMapping
+		records, err := work.Sequence2File.read(all)
+		if err != nil {
+			return work, err
+		}
+		if all {
+			work.Sequence2 = EpitopePayloadOf(RecordEpitopes(records))
+		} else {
+			work.Sequence2 = SequencePayloadOf(records[0].Sequence)
+		}
+	}
+	return work, nil
+}
+
+// read returns the chosen record of the file, or when none is chosen its
+// first record, or all of them
+func (f SequenceFile) read(all bool) ([]seqio.Record, error) {
+	path, err := resolvePath(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := seqio.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("sequence file %q: %w", f.Path, unwrapPath(err))
+	}
+	defer file.Close()
+
+	var records []seqio.Record
+	for {
+		record, err := file.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sequence file %q: %w", f.Path, err)
+		}
+		if f.Record != "" && record.ID != f.Record {
+			continue
+		}
+		records = append(records, record)
+		if f.Record != "" || !all {
+			break
+		}
+	}
+
+	switch {
+	case len(records) > 0:
+		return records, nil
+	case f.Record != "":
+		return nil, fmt.Errorf("sequence file %q has no record %q", f.Path, f.Record)
+	}
+	return nil, fmt.Errorf("sequence file %q has no records", f.Path)
+}
+
+// resolvePath returns where a sequence file is, refusing paths that are not
+// local to the data directory or lead out of it through symbolic links
+func resolvePath(path string) (string, error) {
+	if dataDir == "" {
+		return "", errors.New("sequence files are disabled, the worker has no data directory")
+	}
+	if !filepath.IsLocal(path) {
+		return "", fmt.Errorf("sequence file %q is not a relative path in the data directory", path)
+	}
+
+	root, err := filepath.EvalSymlinks(dataDir)
+	if err != nil {
+		return "", fmt.Errorf("data directory: %w", unwrapPath(err))
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, path))
+	if err != nil {
+		return "", fmt.Errorf("sequence file %q: %w", path, unwrapPath(err))
+	}
+	if rel, err := filepath.Rel(root, resolved); err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("sequence file %q is outside the data directory", path)
+	}
+	return resolved, nil
+}
+
+// unwrapPath drops the path from a file system error, so that errors sent
+// to the server do not tell where the data directory is
+func unwrapPath(err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	return err
+}
+
+// RecordEpitopes returns the sequences of records as epitopes, record IDs
+// that are numbers being kept as epitope IDs
+func RecordEpitopes(records []seqio.Record) []Epitope {
+	epitopes := make([]Epitope, len(records))
+	for i, record := range records {
+		id, _ := strconv.Atoi(record.ID)
+		epitopes[i] = Epitope{ID: id, LinearSequence: record.Sequence}
+	}
+	return epitopes
+}
@@ -0,0 +1,90 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/vsdbmv2/worker-go/epitopeMap"
+)
+
+func TestLoadSequenceFiles(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	files := map[string]string{
+		filepath.Join(dir, "references.fa"): ">ref1\nACGTACGT\n>ref2\nTTTTGGGG\n",
+		filepath.Join(dir, "epitopes.fa"):   ">1\nSIINFEKL\n>gag\nGILGFVFTL\n",
+		filepath.Join(dir, "notes.txt"):     "not sequences\n",
+		filepath.Join(outside, "secret.fa"): ">secret\nAAAA\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.fa"), filepath.Join(dir, "link.fa")); err != nil {
+		t.Fatal(err)
+	}
+
+	SetDataDir(dir)
+	defer SetDataDir("")
+
+	tests := []struct {
+		work    Work
+		want    Work
+		wantErr bool
+	}{
+		{
+			work: Work{Type: GlobalMapping, Sequence1File: &SequenceFile{Path: "references.fa", Record: "ref2"},
+				Sequence2File: &SequenceFile{Path: "references.fa"}},
+			want: Work{Type: GlobalMapping, Sequence1: "TTTTGGGG", Sequence2: SequencePayloadOf("ACGTACGT")},
+		},
+		{
+			work: Work{Type: EpitopeMapping, Sequence2File: &SequenceFile{Path: "epitopes.fa"}},
+			want: Work{Type: EpitopeMapping, Sequence2: EpitopePayloadOf([]Epitope{
+				{ID: 1, LinearSequence: "SIINFEKL"}, {LinearSequence: "GILGFVFTL"}})},
+		},
+		{
+			work: Work{Type: EpitopeMapping, Sequence2File: &SequenceFile{Path: "epitopes.fa", Record: "gag"}},
+			want: Work{Type: EpitopeMapping, Sequence2: EpitopePayloadOf([]Epitope{{LinearSequence: "GILGFVFTL"}})},
+		},
+		{work: Work{Sequence1File: &SequenceFile{Path: "references.fa", Record: "ref3"}}, wantErr: true},
+		{work: Work{Sequence1File: &SequenceFile{Path: "missing.fa"}}, wantErr: true},
+		{work: Work{Sequence1File: &SequenceFile{Path: "notes.txt"}}, wantErr: true},
+		{work: Work{Sequence1File: &SequenceFile{Path: "../" + filepath.Base(outside) + "/secret.fa"}}, wantErr: true},
+		{work: Work{Sequence1File: &SequenceFile{Path: filepath.Join(outside, "secret.fa")}}, wantErr: true},
+		{work: Work{Sequence1File: &SequenceFile{Path: "link.fa"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := LoadSequenceFiles(tt.work)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("LoadSequenceFiles(%+v) error = %v, wantErr %v", tt.work, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			if strings.Contains(err.Error(), dir) {
+				t.Errorf("LoadSequenceFiles(%+v) error = %v, tells where the data directory is", tt.work, err)
+			}
+			continue
+		}
+		got.Sequence1File, got.Sequence2File = nil, nil
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("LoadSequenceFiles(%+v) = %+v, want %+v", tt.work, got, tt.want)
+		}
+	}
+
+	work := Work{Type: "test-echo", Identifier: "job", Sequence1File: &SequenceFile{Path: "references.fa"}}
+	result, loaded := ProcessLoaded(context.Background(), work)
+	if result.Cigar != "ACGTACGT" || loaded.Sequence1 != "ACGTACGT" {
+		t.Errorf("ProcessLoaded(%+v) = %+v, %+v, want the sequence of the file", work, result, loaded)
+	}
+
+	SetDataDir("")
+	if _, err := LoadSequenceFiles(Work{Sequence1File: &SequenceFile{Path: "references.fa"}}); err == nil {
+		t.Errorf("LoadSequenceFiles() without a data directory error = nil, want disabled")
+	}
+}
@@ -0,0 +1,90 @@
+package processor
+
+import (
+	"encoding/json"
+	"strings"
+
+	. "github.com/vsdbmv2/worker-go/epitopeMap"
+)
+
+// SequencePayload is the sequence2 field of a Work: either a single sequence,
+// or an array of epitopes given as strings or as objects with their ID and
+// metadata.
+type SequencePayload struct {
+	sequences []Epitope
+	list      bool      // given as an array
+	sent      []Epitope // sequences before NormalizeWork, when it changed them
+}
+
+// SequencePayloadOf returns the payload of a single sequence
+func SequencePayloadOf(sequence string) SequencePayload {
+	return SequencePayload{sequences: []Epitope{{LinearSequence: sequence}}}
+}
+
+// EpitopePayloadOf returns the payload of an array of epitopes
+func EpitopePayloadOf(epitopes []Epitope) SequencePayload {
+	return SequencePayload{sequences: epitopes, list: true}
+}
+
+// UnmarshalJSON never fails, so that a malformed payload is reported as an
+// invalid-payload error for its own job instead of dropping the whole batch.
+func (p *SequencePayload) UnmarshalJSON(data []byte) error {
+	*p = SequencePayload{}
+
+	var sequence string
+	if err := json.Unmarshal(data, &sequence); err == nil && string(data) != "null" {
+		p.sequences = []Epitope{{LinearSequence: sequence}}
+		return nil
+	}
+
+	var epitopes []Epitope
+	if err := json.Unmarshal(data, &epitopes); err == nil && epitopes != nil {
+		p.sequences = epitopes
+		p.list = true
+	}
+	return nil
+}
+
+// Single returns the sequence of a payload given as a single string
+func (p SequencePayload) Single() (string, bool) {
+	if p.list || len(p.sequences) != 1 {
+		return "", false
+	}
+	return p.sequences[0].LinearSequence, true
+}
+
+// Epitopes returns the epitopes of the payload, a single string being one
+// epitope
+func (p SequencePayload) Epitopes() ([]Epitope, bool) {
+	if !p.list && len(p.sequences) != 1 {
+		return nil, false
+	}
+	return p.sequences, true
+}
+
+// Sent returns the epitopes of the payload as they were sent, in the same
+// order as Epitopes, whatever the case NormalizeWork gave them
+func (p SequencePayload) Sent() []Epitope {
+	if p.sent != nil {
+		return p.sent
+	}
+	return p.sequences
+}
+
+// upper returns the payload with its sequences in uppercase
+func (p SequencePayload) upper() SequencePayload {
+	sequences := make([]Epitope, len(p.sequences))
+	for i, epitope := range p.sequences {
+		epitope.LinearSequence = strings.ToUpper(epitope.LinearSequence)
+		sequences[i] = epitope
+	}
+	return SequencePayload{sequences: sequences, list: p.list, sent: p.Sent()}
+}
+
+// NormalizeWork upper-cases the sequences of work, so that every job type
+// compares residues the same way whatever their case
+func NormalizeWork(work Work) Work {
+	work.Sequence1 = strings.ToUpper(work.Sequence1)
+	work.Sequence2 = work.Sequence2.upper()
+	return work
+}
@@ -0,0 +1,43 @@
+package processor
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	. "github.com/vsdbmv2/worker-go/epitopeMap"
+)
+
+func TestNormalizeWork(t *testing.T) {
+	tests := []struct {
+		payload      string
+		wantEpitopes []Epitope
+		wantSent     []Epitope
+	}{
+		{
+			payload:      `"acgt"`,
+			wantEpitopes: []Epitope{{LinearSequence: "ACGT"}},
+			wantSent:     []Epitope{{LinearSequence: "acgt"}},
+		},
+		{
+			payload:      `[{"idEpitope": 3, "linearSequence": "siiNFEKL"}, "GCT"]`,
+			wantEpitopes: []Epitope{{ID: 3, LinearSequence: "SIINFEKL"}, {LinearSequence: "GCT"}},
+			wantSent:     []Epitope{{ID: 3, LinearSequence: "siiNFEKL"}, {LinearSequence: "GCT"}},
+		},
+	}
+
+	for _, tt := range tests {
+		var work Work
+		if err := json.Unmarshal([]byte(`{"sequence1": "acgt", "sequence2": `+tt.payload+`}`), &work); err != nil {
+			t.Fatal(err)
+		}
+		work = NormalizeWork(NormalizeWork(work))
+		epitopes, _ := work.Sequence2.Epitopes()
+		if work.Sequence1 != "ACGT" || !reflect.DeepEqual(epitopes, tt.wantEpitopes) {
+			t.Errorf("NormalizeWork(%v) = %v, %+v, want ACGT, %+v", tt.payload, work.Sequence1, epitopes, tt.wantEpitopes)
+		}
+		if sent := work.Sequence2.Sent(); !reflect.DeepEqual(sent, tt.wantSent) {
+			t.Errorf("NormalizeWork(%v) sent %+v, want %+v", tt.payload, sent, tt.wantSent)
+		}
+	}
+}
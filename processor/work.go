@@ -0,0 +1,73 @@
+package processor
+
+import (
+	"encoding/json"
+
+	. "github.com/vsdbmv2/worker-go/epitopeMap"
+	needleman_wunsh "github.com/vsdbmv2/worker-go/needlemanWunsh"
+)
+
+// WorkType represents the type of mapping job
+type WorkType string
+
+const (
+	GlobalMapping       WorkType = "global-mapping"
+	GlocalMapping       WorkType = "glocal-mapping" // Whole query against part of the reference
+	LocalMapping        WorkType = "local-mapping"
+	EpitopeMapping      WorkType = "epitope-mapping"
+	EpitopeBatchSummary WorkType = "epitope-summary" // Result summarizing an epitope mapping batch
+)
+
+// Work represents a single mapping job
+type Work struct {
+	Type           WorkType                       `json:"type"`
+	ID1            int                            `json:"id1"`
+	Organism       string                         `json:"organism"`
+	Sequence1      string                         `json:"sequence1"`
+	Sequence2      SequencePayload                `json:"sequence2"` // String, or array of epitopes for epitope mapping
+	ID2            int                            `json:"id2"`
+	Identifier     string                         `json:"identifier"`
+	IDSubtype      int                            `json:"idSubtype,omitempty"`
+	Scoring        *needleman_wunsh.ScoringParams `json:"scoring,omitempty"`        // Global and glocal mapping scoring, defaults when omitted
+	Matrix         string                         `json:"matrix,omitempty"`         // Substitution matrix name for global, glocal and local mapping, e.g. BLOSUM62
+	Band           *needleman_wunsh.BandParams    `json:"band,omitempty"`           // Global mapping within a band around the diagonal, the whole matrix when omitted, refused by glocal mapping
+	MaxDistance    int                            `json:"maxDistance,omitempty"`    // Differences allowed in epitope mapping, exact matches only when 0
+	DistanceMetric string                         `json:"distanceMetric,omitempty"` // "hamming" (default) or "edit"
+	GeneticCode    int                            `json:"geneticCode,omitempty"`    // NCBI table translating sequence1 in six frames for epitope mapping, none when 0
+	Ambiguity      string                         `json:"ambiguity,omitempty"`      // How ambiguity codes match in epitope mapping: "exact" (default), "iupac" or "mismatch"
+	Alphabet       string                         `json:"alphabet,omitempty"`       // Alphabet of the ambiguity codes: "protein" (default) or "nucleotide"
+	BatchID        string                         `json:"batchId,omitempty"`        // Epitope mapping batch to summarize once its batchSize jobs are done
+	BatchSize      int                            `json:"batchSize,omitempty"`      // Number of jobs of the batch sent to this worker
+	Sequence1File  *SequenceFile                  `json:"sequence1File,omitempty"`  // Local file read instead of sequence1
+	Sequence2File  *SequenceFile                  `json:"sequence2File,omitempty"`  // Local file read instead of sequence2
+	TimeoutMs      int                            `json:"timeoutMs,omitempty"`      // Milliseconds the job may run before failing with a timeout, no limit when 0
+}
+
+// Result represents the mapping result
+type Result struct {
+	Organism          string                          `json:"organism"`
+	Type              WorkType                        `json:"type"`
+	Identifier        string                          `json:"identifier"`
+	MapInit           int                             `json:"map_init,omitempty"`
+	MapEnd            int                             `json:"map_end,omitempty"`
+	CoveragePct       float64                         `json:"coverage_pct,omitempty"`
+	IDSequence        int                             `json:"idSequence,omitempty"`
+	AlignmentScore    int                             `json:"alignment_score,omitempty"`
+	IDSequenceSubtype int                             `json:"idSequenceSubtype,omitempty"`
+	IDSubtype         int                             `json:"idSubtype,omitempty"`
+	EpitopeMaps       []EpitopeMap                    `json:"epitope_maps,omitempty"`
+	EpitopeSummaries  []EpitopeSummary                `json:"epitope_summary,omitempty"`
+	RefStart          int                             `json:"ref_start,omitempty"`
+	RefEnd            int                             `json:"ref_end,omitempty"`
+	QueryStart        int                             `json:"query_start,omitempty"`
+	QueryEnd          int                             `json:"query_end,omitempty"`
+	AlignedReference  string                          `json:"aligned_reference,omitempty"`
+	AlignedQuery      string                          `json:"aligned_query,omitempty"`
+	IdentityPct       float64                         `json:"identity_pct,omitempty"`
+	SimilarityPct     float64                         `json:"similarity_pct,omitempty"`
+	Gaps              int                             `json:"gaps,omitempty"`
+	Cigar             string                          `json:"cigar,omitempty"`
+	Operations        []needleman_wunsh.EditOperation `json:"operations,omitempty"`
+	Payload           json.RawMessage                 `json:"payload,omitempty"` // Results of job types that fit none of the fields above, as JSON
+	Error             *JobError                       `json:"error,omitempty"`
+}
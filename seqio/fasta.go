@@ -0,0 +1,57 @@
+package seqio
+
+import (
+	"io"
+	"strings"
+)
+
+// FastaReader reads FASTA records. Sequence lines are joined without their
+// whitespace, and blank lines and ';' comment lines are skipped.
+type FastaReader struct {
+	lines *lineReader
+}
+
+// NewFastaReader returns a reader of the FASTA records of r
+func NewFastaReader(r io.Reader) *FastaReader {
+	return &FastaReader{lines: newLineReader(r)}
+}
+
+// Read returns the next record
+func (r *FastaReader) Read() (Record, error) {
+	var record Record
+	for {
+		line, err := r.lines.next()
+		if err != nil {
+			return Record{}, err
+		}
+		if line = strings.TrimSpace(line); line == "" || line[0] == ';' {
+			continue
+		}
+		if line[0] != '>' {
+			return Record{}, r.lines.errorf("sequence before the first FASTA header")
+		}
+		record.ID, record.Description = splitHeader(line[1:])
+		break
+	}
+
+	var sequence []byte
+	for {
+		line, err := r.lines.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Record{}, err
+		}
+		if strings.HasPrefix(line, ">") {
+			r.lines.unread(line)
+			break
+		}
+		if strings.HasPrefix(line, ";") {
+			continue
+		}
+		sequence = appendResidues(sequence, line)
+	}
+	record.Sequence = string(sequence)
+	return record, nil
+}
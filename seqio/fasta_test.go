@@ -0,0 +1,43 @@
+package seqio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFastaReader(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []Record
+		wantErr bool
+	}{
+		{
+			input: ">seq1 first sequence\nACGT\nAC GT\r\n\n>seq2\n;comment\nTTTT\n",
+			want: []Record{
+				{ID: "seq1", Description: "first sequence", Sequence: "ACGTACGT"},
+				{ID: "seq2", Sequence: "TTTT"},
+			},
+		},
+		{
+			input: "; leading comment\n>empty\n>last  spaced   description \nMKV",
+			want: []Record{
+				{ID: "empty"},
+				{ID: "last", Description: "spaced   description", Sequence: "MKV"},
+			},
+		},
+		{input: "", want: nil},
+		{input: "ACGT\n>seq\nACGT\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ReadAll(NewFastaReader(strings.NewReader(tt.input)))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ReadAll(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReadAll(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
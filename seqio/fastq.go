@@ -0,0 +1,79 @@
+package seqio
+
+import (
+	"io"
+	"strings"
+)
+
+// phredOffset is the character of quality 0 in Sanger and Illumina 1.8+
+// FASTQ files
+const phredOffset = '!'
+
+// FastqReader reads FASTQ records. The sequence and the quality may span
+// several lines, the quality ending once it is as long as the sequence.
+type FastqReader struct {
+	lines *lineReader
+}
+
+// NewFastqReader returns a reader of the FASTQ records of r
+func NewFastqReader(r io.Reader) *FastqReader {
+	return &FastqReader{lines: newLineReader(r)}
+}
+
+// Read returns the next record, its quality decoded to Phred scores
+func (r *FastqReader) Read() (Record, error) {
+	var record Record
+	for {
+		line, err := r.lines.next()
+		if err != nil {
+			return Record{}, err
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		if line[0] != '@' {
+			return Record{}, r.lines.errorf("expected a FASTQ header starting with @")
+		}
+		record.ID, record.Description = splitHeader(line[1:])
+		break
+	}
+
+	var sequence []byte
+	for {
+		line, err := r.lines.next()
+		if err == io.EOF {
+			return Record{}, r.lines.errorf("record %s ends without a quality", record.ID)
+		}
+		if err != nil {
+			return Record{}, err
+		}
+		if strings.HasPrefix(line, "+") {
+			break
+		}
+		sequence = appendResidues(sequence, line)
+	}
+
+	quality := make([]byte, 0, len(sequence))
+	for len(quality) < len(sequence) {
+		line, err := r.lines.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Record{}, err
+		}
+		for i := 0; i < len(line); i++ {
+			if line[i] < phredOffset || line[i] > '~' {
+				return Record{}, r.lines.errorf("invalid quality character %q", line[i])
+			}
+			quality = append(quality, line[i]-phredOffset)
+		}
+	}
+	if len(quality) != len(sequence) {
+		return Record{}, r.lines.errorf("record %s has %d quality scores for %d residues", record.ID, len(quality), len(sequence))
+	}
+
+	record.Sequence = string(sequence)
+	record.Quality = quality
+	return record, nil
+}
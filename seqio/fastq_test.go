@@ -0,0 +1,38 @@
+package seqio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFastqReader(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []Record
+		wantErr bool
+	}{
+		{
+			input: "@read1 lane 1\nACGT\n+\n!+5I\n@read2\nAC\nGT\n+read2\n@@\nII\n",
+			want: []Record{
+				{ID: "read1", Description: "lane 1", Sequence: "ACGT", Quality: []byte{0, 10, 20, 40}},
+				{ID: "read2", Sequence: "ACGT", Quality: []byte{31, 31, 40, 40}},
+			},
+		},
+		{input: "@read\nACGT\n+\nIII\n", wantErr: true},
+		{input: "@read\nACGT\n", wantErr: true},
+		{input: ">read\nACGT\n+\nIIII\n", wantErr: true},
+		{input: "@read\nACGT\n+\nII I\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ReadAll(NewFastqReader(strings.NewReader(tt.input)))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ReadAll(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReadAll(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package seqio
+
+import (
+	"io"
+	"strings"
+)
+
+// GenBankReader reads GenBank flat file records, keeping their locus name,
+// definition and sequence
+type GenBankReader struct {
+	lines *lineReader
+}
+
+// NewGenBankReader returns a reader of the GenBank records of r
+func NewGenBankReader(r io.Reader) *GenBankReader {
+	return &GenBankReader{lines: newLineReader(r)}
+}
+
+// Read returns the next record, its sequence as written in the file,
+// usually in lowercase
+func (r *GenBankReader) Read() (Record, error) {
+	var record Record
+	for {
+		line, err := r.lines.next()
+		if err != nil {
+			return Record{}, err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "LOCUS") {
+			return Record{}, r.lines.errorf("expected a GenBank LOCUS line")
+		}
+		if fields := strings.Fields(line); len(fields) > 1 {
+			record.ID = fields[1]
+		}
+		break
+	}
+
+	var definition []string
+	var sequence []byte
+	section := ""
+	for {
+		line, err := r.lines.next()
+		if err == io.EOF {
+			return Record{}, r.lines.errorf("record %s ends without //", record.ID)
+		}
+		if err != nil {
+			return Record{}, err
+		}
+		if strings.HasPrefix(line, "//") {
+			break
+		}
+
+		// Keywords start at the first column, their continuation lines are
+		// indented
+		if line != "" && line[0] != ' ' {
+			keyword, rest, _ := strings.Cut(line, " ")
+			section = keyword
+			line = rest
+		}
+		switch section {
+		case "DEFINITION":
+			definition = append(definition, strings.TrimSpace(line))
+		case "ORIGIN":
+			// Residues come after the position of their first one
+			if fields := strings.Fields(line); len(fields) > 1 {
+				sequence = appendResidues(sequence, strings.Join(fields[1:], ""))
+			}
+		}
+	}
+
+	record.Description = strings.Join(definition, " ")
+	record.Sequence = string(sequence)
+	return record, nil
+}
@@ -0,0 +1,48 @@
+package seqio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const genBankRecord = `LOCUS       AB000001                  24 bp    DNA     linear   VRL 01-JAN-2000
+DEFINITION  Example virus gene for a test protein,
+            complete cds.
+ACCESSION   AB000001
+FEATURES             Location/Qualifiers
+     source          1..24
+                     /organism="Example virus"
+ORIGIN
+        1 atggctagct agctagctag ctaa
+//
+`
+
+func TestGenBankReader(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []Record
+		wantErr bool
+	}{
+		{
+			input: genBankRecord + "\n" + strings.Replace(genBankRecord, "AB000001 ", "AB000002 ", 1),
+			want: []Record{
+				{ID: "AB000001", Description: "Example virus gene for a test protein, complete cds.", Sequence: "atggctagctagctagctagctaa"},
+				{ID: "AB000002", Description: "Example virus gene for a test protein, complete cds.", Sequence: "atggctagctagctagctagctaa"},
+			},
+		},
+		{input: strings.TrimSuffix(genBankRecord, "//\n"), wantErr: true},
+		{input: "DEFINITION  no locus\n//\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ReadAll(NewGenBankReader(strings.NewReader(tt.input)))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ReadAll(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReadAll(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
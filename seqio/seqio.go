@@ -0,0 +1,197 @@
+// Package seqio streams sequence records from FASTA, FASTQ and GenBank
+// files, plain or gzip-compressed.
+package seqio
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Record is a sequence read from a file
+type Record struct {
+	ID          string // first word of a FASTA or FASTQ header, locus name in GenBank
+	Description string // rest of the header, definition in GenBank
+	Sequence    string // residues as written, whitespace and GenBank numbering removed
+	Quality     []byte // Phred quality of every residue, FASTQ only
+}
+
+// Reader reads records one at a time, returning io.EOF after the last one
+type Reader interface {
+	Read() (Record, error)
+}
+
+// Format is a sequence file format
+type Format string
+
+const (
+	FASTA   Format = "fasta"
+	FASTQ   Format = "fastq"
+	GenBank Format = "genbank"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// NewReader returns a reader of the records of r, decompressing it if it is
+// gzipped and telling its format from its first line.
+func NewReader(r io.Reader) (Reader, error) {
+	buffered := bufio.NewReader(r)
+	if magic, _ := buffered.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		decompressed, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		buffered = bufio.NewReader(decompressed)
+	}
+
+	format, err := detect(buffered)
+	if err != nil {
+		return nil, err
+	}
+	return NewFormatReader(buffered, format)
+}
+
+// NewFormatReader returns a reader of the records of r, in the given format
+func NewFormatReader(r io.Reader, format Format) (Reader, error) {
+	switch format {
+	case FASTA:
+		return NewFastaReader(r), nil
+	case FASTQ:
+		return NewFastqReader(r), nil
+	case GenBank:
+		return NewGenBankReader(r), nil
+	}
+	return nil, fmt.Errorf("unknown sequence format %q", format)
+}
+
+// detect tells the format of r from its first non-blank line. An input
+// with nothing but whitespace is read as FASTA, which has no records.
+func detect(r *bufio.Reader) (Format, error) {
+	peeked, err := r.Peek(512)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	line := bytes.TrimLeft(peeked, " \t\r\n")
+	switch {
+	case len(line) == 0, line[0] == '>', line[0] == ';':
+		return FASTA, nil
+	case line[0] == '@':
+		return FASTQ, nil
+	case bytes.HasPrefix(line, []byte("LOCUS")):
+		return GenBank, nil
+	}
+	return "", errors.New("unknown sequence format, expected FASTA, FASTQ or GenBank")
+}
+
+// File is a sequence file being read
+type File struct {
+	Reader
+	f *os.File
+}
+
+// Open opens the sequence file at path for reading. Its errors are
+// *fs.PathError.
+func Open(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, &fs.PathError{Op: "read", Path: path, Err: err}
+	}
+	return &File{Reader: r, f: f}, nil
+}
+
+// Close closes the file
+func (f *File) Close() error {
+	return f.f.Close()
+}
+
+// ReadAll reads the remaining records of r
+func ReadAll(r Reader) ([]Record, error) {
+	var records []Record
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
+
+// lineReader reads a file a line at a time, without line endings, and can
+// put back the last line read
+type lineReader struct {
+	r       *bufio.Reader
+	line    int // number of the last line read
+	pending *string
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	buffered, ok := r.(*bufio.Reader)
+	if !ok {
+		buffered = bufio.NewReader(r)
+	}
+	return &lineReader{r: buffered}
+}
+
+// next returns the next line, and io.EOF once there are none left
+func (l *lineReader) next() (string, error) {
+	l.line++
+	if l.pending != nil {
+		line := *l.pending
+		l.pending = nil
+		return line, nil
+	}
+
+	line, err := l.r.ReadString('\n')
+	if err == io.EOF && line == "" {
+		l.line--
+		return "", io.EOF
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// unread puts line back, to be returned by the next call to next
+func (l *lineReader) unread(line string) {
+	l.line--
+	l.pending = &line
+}
+
+// errorf returns an error about the last line read
+func (l *lineReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", l.line, fmt.Sprintf(format, args...))
+}
+
+// splitHeader splits a FASTA or FASTQ header, without its marker, into the
+// ID and the description of its record
+func splitHeader(header string) (string, string) {
+	id, description, _ := strings.Cut(strings.TrimSpace(header), " ")
+	return id, strings.TrimSpace(description)
+}
+
+// appendResidues appends the residues of line to sequence, leaving out
+// whitespace
+func appendResidues(sequence []byte, line string) []byte {
+	for i := 0; i < len(line); i++ {
+		if c := line[i]; c != ' ' && c != '\t' {
+			sequence = append(sequence, c)
+		}
+	}
+	return sequence
+}
@@ -0,0 +1,83 @@
+package seqio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func gzipped(t *testing.T, data string) string {
+	var buffer bytes.Buffer
+	w := gzip.NewWriter(&buffer)
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.String()
+}
+
+func TestNewReader(t *testing.T) {
+	fasta := "\n>seq\nACGT\n"
+	fastq := "@read\nACGT\n+\nIIII\n"
+
+	tests := []struct {
+		input   string
+		want    []Record
+		wantErr bool
+	}{
+		{input: fasta, want: []Record{{ID: "seq", Sequence: "ACGT"}}},
+		{input: gzipped(t, fasta), want: []Record{{ID: "seq", Sequence: "ACGT"}}},
+		{input: gzipped(t, fastq), want: []Record{{ID: "read", Sequence: "ACGT", Quality: []byte{40, 40, 40, 40}}}},
+		{input: genBankRecord, want: []Record{{ID: "AB000001", Description: "Example virus gene for a test protein, complete cds.", Sequence: "atggctagctagctagctagctaa"}}},
+		{input: "  \n", want: nil},
+		{input: "ACGT\n", wantErr: true},
+		{input: "\x1f\x8bnot gzip", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		r, err := NewReader(bytes.NewReader([]byte(tt.input)))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewReader(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		got, err := ReadAll(r)
+		if err != nil {
+			t.Errorf("ReadAll(%q) unexpected error %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReadAll(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sequences.fa.gz")
+	if err := os.WriteFile(path, []byte(gzipped(t, ">a\nAC\n>b\nGT\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error %v", err)
+	}
+	defer f.Close()
+
+	got, err := ReadAll(f)
+	want := []Record{{ID: "a", Sequence: "AC"}, {ID: "b", Sequence: "GT"}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadAll(Open()) = %+v, %v, want %+v", got, err, want)
+	}
+
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.fa")); err == nil {
+		t.Errorf("Open(missing.fa) error = nil, want not found")
+	}
+}
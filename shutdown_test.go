@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	. "github.com/vsdbmv2/worker-go/processor"
+)
+
+func init() {
+	// Runs until canceled, to be handed back on shutdown
+	Register("test-block", ProcessorFunc(func(ctx context.Context, work Work) (Result, error) {
+		<-ctx.Done()
+		return Result{}, ctx.Err()
+	}))
+}
+
+func TestShutdown(t *testing.T) {
+	type event struct {
+		Type    string
+		Payload json.RawMessage
+	}
+	received := make(chan []event, 1)
+	closed := make(chan error, 1)
+	host := server(t, func(ws *websocket.Conn) {
+		var events []event
+		for {
+			var e event
+			if err := ws.ReadJSON(&e); err != nil {
+				closed <- err
+				break
+			}
+			events = append(events, e)
+		}
+		received <- events
+	})
+
+	// Offline with a long backoff built up, which a shutdown does not wait
+	c := newConnection(host)
+	c.attempt = 10
+	c.attached = time.Now()
+
+	results := make(chan Result)
+	dispatched := make(chan struct{})
+	go func() {
+		dispatchResults(c, results)
+		close(dispatched)
+	}()
+	workers := newPool(1, 1, results)
+	for _, work := range []Work{
+		{Type: GlobalMapping, Identifier: "done", Sequence1: "ACGT", Sequence2: SequencePayloadOf("ACGT")},
+		{Type: "test-block", Identifier: "running"},
+		{Type: "test-block", Identifier: "queued"},
+	} {
+		if !workers.submit(context.Background(), work) {
+			t.Fatalf("submit(%v) failed", work.Identifier)
+		}
+	}
+
+	start := time.Now()
+	shutdown(c, workers, 100*time.Millisecond, results, dispatched, func() []string { return []string{"late"} })
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shutdown took %v, waiting for the backoff", elapsed)
+	}
+
+	events := <-received
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	if want := []string{"work-complete", "worker-leaving"}; !reflect.DeepEqual(types, want) {
+		t.Fatalf("server received %v, want %v", types, want)
+	}
+
+	var leaving struct{ Unfinished []string }
+	if err := json.Unmarshal(events[1].Payload, &leaving); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"late", "queued", "running"}; !reflect.DeepEqual(leaving.Unfinished, want) {
+		t.Errorf("unfinished = %v, want %v", leaving.Unfinished, want)
+	}
+	if err := <-closed; !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("server read %v, want a normal closure", err)
+	}
+}
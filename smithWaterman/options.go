@@ -0,0 +1,55 @@
+package smith_waterman
+
+import (
+	substitution_matrix "github.com/vsdbmv2/worker-go/substitutionMatrix"
+)
+
+type config struct {
+	matrix *substitution_matrix.Matrix
+}
+
+// Option customizes a SmithWaterman alignment
+type Option func(*config)
+
+// WithMatrix scores residue pairs with a substitution matrix instead of the
+// match and mismatch scores, keeping the default gap penalties.
+func WithMatrix(matrix *substitution_matrix.Matrix) Option {
+	return func(c *config) {
+		c.matrix = matrix
+	}
+}
+
+func newConfig(opts []Option) config {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// score returns the similarity of two uppercase residues
+func (c config) score(a, b byte) int {
+	if c.matrix != nil {
+		return c.matrix.Score(a, b)
+	}
+	if a == b {
+		return mt
+	}
+	return mst
+}
+
+// maxScore returns the highest score of a pair of residues
+func (c config) maxScore() int {
+	if c.matrix == nil {
+		return mt
+	}
+	best := c.matrix.Score(c.matrix.Alphabet[0], c.matrix.Alphabet[0])
+	for i := 0; i < len(c.matrix.Alphabet); i++ {
+		for j := 0; j < len(c.matrix.Alphabet); j++ {
+			if score := c.matrix.Score(c.matrix.Alphabet[i], c.matrix.Alphabet[j]); score > best {
+				best = score
+			}
+		}
+	}
+	return best
+}
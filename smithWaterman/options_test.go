@@ -0,0 +1,36 @@
+package smith_waterman
+
+import (
+	"testing"
+
+	substitution_matrix "github.com/vsdbmv2/worker-go/substitutionMatrix"
+)
+
+func TestSmithWatermanWithMatrix(t *testing.T) {
+	tests := []struct {
+		seq1      string
+		seq2      string
+		matrix    string
+		wantScore int
+	}{
+		{seq1: "gccagccccctgatgggggcgacactcc", seq2: "ctgatgggggcgtcactcc", matrix: "NUC.4.4", wantScore: 86},
+		{seq1: "ACGTRYACGT", seq2: "ACGTAAACGT", matrix: "NUC.4.4", wantScore: 37},
+		{seq1: "HEAGAWGHEE", seq2: "PAWHEAE", matrix: "BLOSUM62", wantScore: 18},
+		{seq1: "MKWVTFISLL", seq2: "MKWVTFISLL", matrix: "BLOSUM62", wantScore: 52},
+	}
+
+	for _, tt := range tests {
+		matrix, err := substitution_matrix.Lookup(tt.matrix)
+		if err != nil {
+			t.Fatalf("Lookup(%v) error = %v", tt.matrix, err)
+		}
+		score, err := SmithWaterman(tt.seq1, tt.seq2, WithMatrix(matrix))
+		if err != nil {
+			t.Errorf("SmithWaterman(%v, %v, %v) unexpected error %v", tt.seq1, tt.seq2, tt.matrix, err)
+			continue
+		}
+		if score != tt.wantScore {
+			t.Errorf("SmithWaterman(%v, %v, %v) = %v, want %v", tt.seq1, tt.seq2, tt.matrix, score, tt.wantScore)
+		}
+	}
+}
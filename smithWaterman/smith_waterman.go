@@ -1,13 +1,27 @@
 package smith_waterman
 
 import (
+	"context"
 	"errors"
 	"math"
 	"strings"
+	"unicode/utf8"
+
+	substitution_matrix "github.com/vsdbmv2/worker-go/substitutionMatrix"
+)
+
+// Scoring parameters
+const (
+	ge  = -1  // gap extension penalty
+	go_ = -10 // gap opening penalty
+	mt  = 5   // match score
+	mst = -4  // mismatch score
 )
 
-// computeSmithWaterman calculates the alignment score using the Smith-Waterman algorithm
-func computeSmithWaterman(s1, s2 []string, ge, go_, mt, mst int, currentLine, lastLine []int) int {
+// computeSmithWaterman calculates the alignment score using the Smith-Waterman algorithm.
+// When matrix is not nil it scores each pair of residues instead of mt and mst.
+// It checks ctx before every row, stopping with its error once it is done.
+func computeSmithWaterman(ctx context.Context, s1, s2 []string, ge, go_, mt, mst int, matrix *substitution_matrix.Matrix, currentLine, lastLine []int) (int, error) {
 	bestScore := 0
 	var (
 		similarity            int   // similarity between the chars (match or mismatch)
@@ -20,12 +34,18 @@ func computeSmithWaterman(s1, s2 []string, ge, go_, mt, mst int, currentLine, la
 	)
 
 	for i := 1; i <= len(s1); i++ {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
 		leftScore = math.MinInt32
 		lastDiag = 0
 
 		for j := 1; j <= len(s2); j++ {
 			// Calculate similarity score
-			if s1[i-1] == s2[j-1] {
+			if matrix != nil {
+				similarity = lastDiag + matrix.Score(s1[i-1][0], s2[j-1][0])
+			} else if s1[i-1] == s2[j-1] {
 				similarity = lastDiag + mt
 			} else {
 				similarity = lastDiag + mst
@@ -62,11 +82,17 @@ func computeSmithWaterman(s1, s2 []string, ge, go_, mt, mst int, currentLine, la
 		}
 	}
 
-	return bestScore
+	return bestScore, nil
 }
 
 // ComputeLocalAlignment performs local sequence alignment between two sequences
-func SmithWaterman(referenceSequence, querySequence string) (int, error) {
+func SmithWaterman(referenceSequence, querySequence string, opts ...Option) (int, error) {
+	return SmithWatermanContext(context.Background(), referenceSequence, querySequence, opts...)
+}
+
+// SmithWatermanContext is SmithWaterman, giving up with the error of ctx once
+// it is done
+func SmithWatermanContext(ctx context.Context, referenceSequence, querySequence string, opts ...Option) (int, error) {
 	// Validate input sequences
 	if len(referenceSequence) == 0 {
 		return 0, errors.New("empty reference sequence")
@@ -75,26 +101,26 @@ func SmithWaterman(referenceSequence, querySequence string) (int, error) {
 		return 0, errors.New("empty query sequence")
 	}
 
-	// Convert sequences to uppercase and split into slices
-	reference := strings.Split(strings.ToUpper(referenceSequence), "")
-	query := strings.Split(strings.ToUpper(querySequence), "")
+	cfg := newConfig(opts)
+	referenceSequence = strings.ToUpper(referenceSequence)
+	querySequence = strings.ToUpper(querySequence)
 
-	// Initialize parameters
-	const (
-		ge  = -1  // gap extension penalty
-		go_ = -10 // gap opening penalty
-		mt  = 5   // match score
-		mst = -4  // mismatch score
-	)
+	// The striped kernel scores bytes, which are characters in ASCII only
+	if isASCII(referenceSequence) && isASCII(querySequence) {
+		end, err := stripedSmithWaterman(ctx, []byte(referenceSequence), []byte(querySequence), cfg)
+		return end.score, err
+	}
+
+	// Split sequences into slices of characters
+	reference := strings.Split(referenceSequence, "")
+	query := strings.Split(querySequence, "")
 
 	// Initialize matrices
 	lastLine := make([]int, len(query)+1)
 	currentLine := make([]int, len(query)+1)
 
 	// Compute optimal alignment
-	score := computeSmithWaterman(reference, query, ge, go_, mt, mst, currentLine, lastLine)
-
-	return score, nil
+	return computeSmithWaterman(ctx, reference, query, ge, go_, mt, mst, cfg.matrix, currentLine, lastLine)
 }
 
 // max returns the maximum value from a slice of integers
@@ -109,4 +135,14 @@ func max(values []int) int {
 		}
 	}
 	return maxVal
+}
+
+// isASCII reports whether s holds ASCII characters only
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
 }
\ No newline at end of file
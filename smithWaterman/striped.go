@@ -0,0 +1,193 @@
+package smith_waterman
+
+import (
+	"context"
+	"math"
+)
+
+// lanes is the number of query positions scored side by side, as many as
+// 16-bit scores in an SSE2 register. In Go, updating a fixed-size array of
+// lanes with the same operations keeps the loops free of bounds checks.
+const lanes = 8
+
+// vector holds one score per lane
+type vector [lanes]int32
+
+// Score of the padding positions after the end of the query, low enough to
+// never start an alignment and far enough from the int32 limits to never
+// overflow
+const paddingScore = math.MinInt32 / 4
+
+// stripedSmithWaterman returns the same score as computeSmithWaterman, with
+// the 16-bit vector kernel where there is one and the scores fit in it, and
+// the cell where it lies, the last one in row order when several have it. A
+// score of 0 lies nowhere, in the zero cell.
+func stripedSmithWaterman(ctx context.Context, reference, query []byte, cfg config) (cell, error) {
+	if end, ok, err := stripedSmithWaterman16(ctx, reference, query, cfg); ok || err != nil {
+		return end, err
+	}
+	return stripedSmithWaterman32(ctx, reference, query, cfg)
+}
+
+// stripedSmithWaterman32 computes the score in Go with Farrar's striped
+// layout: query position j sits in lane j / segments of segment
+// j % segments, so that the vertical and diagonal dependencies of a
+// whole segment are resolved at once, and the horizontal gaps crossing
+// segments are fixed by a lazy loop that usually stops after a few segments.
+// Residues are scored through a query profile, which holds the scores of a
+// reference residue against every query position, in the striped layout.
+func stripedSmithWaterman32(ctx context.Context, reference, query []byte, cfg config) (cell, error) {
+	segments := (len(query) + lanes - 1) / lanes
+	var profiles [256][]vector
+
+	// Scores of the previous and the current row, of the vertical gaps
+	// ending on the next row, and of the horizontal gaps entering each
+	// segment of the current row
+	hLoad := make([]vector, segments)
+	hStore := make([]vector, segments)
+	e := make([]vector, segments)
+	fIn := make([]vector, segments)
+	for k := range e {
+		for l := range lanes {
+			e[k][l] = ge
+		}
+	}
+
+	// The last row reaching the best score, where its column is looked up
+	// once the score is known
+	var end cell
+	endRow := make([]vector, segments)
+
+	for i, residue := range reference {
+		if err := ctx.Err(); err != nil {
+			return cell{}, err
+		}
+
+		profile := profiles[residue]
+		if profile == nil {
+			profile = queryProfile(residue, query, segments, cfg)
+			profiles[residue] = profile
+		}
+		hLoad, hStore = hStore, hLoad
+		var best vector
+
+		// The diagonal of the first segment is the last segment of the row
+		// above, one lane down, the score left of the query being 0
+		var f vector
+		h := shift(hLoad[segments-1], 0)
+		for l := range lanes {
+			f[l] = paddingScore
+		}
+
+		for k := 0; k < segments; k++ {
+			scores, up, store := &profile[k], &e[k], &hStore[k]
+			fIn[k] = f
+			for l := range lanes {
+				score := max32(max32(h[l]+scores[l], up[l]), max32(f[l], 0))
+				best[l] = max32(best[l], score)
+				store[l] = score
+
+				open := score + go_
+				up[l] = max32(up[l]+ge, open)
+				f[l] = max32(f[l]+ge, open)
+			}
+			h = hLoad[k]
+		}
+
+		// Carry the horizontal gaps of each lane over to the next one, for
+		// as long as they score more than the gaps already counted. Past
+		// that point, they only get lower than the gaps counted.
+		f = shift(f, paddingScore)
+		for k := 0; exceeds(f, fIn[k]); {
+			store, up, in := &hStore[k], &e[k], &fIn[k]
+			for l := range lanes {
+				in[l] = max32(in[l], f[l])
+				if f[l] > store[l] {
+					store[l] = f[l]
+					best[l] = max32(best[l], f[l])
+					up[l] = max32(up[l], f[l]+go_)
+				}
+				f[l] += ge
+			}
+
+			if k++; k == segments {
+				k = 0
+				f = shift(f, paddingScore)
+			}
+		}
+
+		if score := int(maxLane(best)); score >= end.score {
+			end.score, end.i = score, i+1
+			copy(endRow, hStore)
+		}
+	}
+
+	if end.score == 0 {
+		return cell{}, nil
+	}
+	end.j = lastColumn(endRow, int32(end.score), len(query))
+	return end, nil
+}
+
+// lastColumn returns the last query position, counted from 1, at which a
+// striped row scores score
+func lastColumn(row []vector, score int32, length int) int {
+	for j := length - 1; j >= 0; j-- {
+		if row[j%len(row)][j/len(row)] == score {
+			return j + 1
+		}
+	}
+	return 0
+}
+
+// queryProfile returns the scores of residue against every query position,
+// in the striped layout
+func queryProfile(residue byte, query []byte, segments int, cfg config) []vector {
+	profile := make([]vector, segments)
+	for k := range profile {
+		for l := range lanes {
+			j := l*segments + k
+			if j < len(query) {
+				profile[k][l] = int32(cfg.score(residue, query[j]))
+			} else {
+				profile[k][l] = paddingScore
+			}
+		}
+	}
+	return profile
+}
+
+// shift moves every score one lane up, the first lane getting first
+func shift(v vector, first int32) vector {
+	copy(v[1:], v[:lanes-1])
+	v[0] = first
+	return v
+}
+
+// maxLane returns the highest score of v
+func maxLane(v vector) int32 {
+	score := v[0]
+	for _, lane := range v[1:] {
+		score = max32(score, lane)
+	}
+	return score
+}
+
+// exceeds reports whether any lane of f scores more than the same lane of g
+func exceeds(f, g vector) bool {
+	for l := range lanes {
+		if f[l] > g[l] {
+			return true
+		}
+	}
+	return false
+}
+
+// max32 returns the larger of a and b. The package max works on slices,
+// which would allocate in the inner loops.
+func max32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
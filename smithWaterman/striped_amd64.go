@@ -0,0 +1,122 @@
+//go:build !purego
+
+package smith_waterman
+
+import (
+	"context"
+	"math"
+)
+
+// vector16 holds one 16-bit score per lane, laid out as in an SSE2 register
+type vector16 [lanes]int16
+
+// Gap opening and extension penalties in every lane
+var gaps16 = [2]vector16{broadcast16(go_), broadcast16(ge)}
+
+// stripedRow16 scores a row of the striped matrix, with the profile of its
+// reference residue, then runs the lazy loop over the horizontal gaps. It
+// raises best to the scores of the row.
+//
+//go:noescape
+func stripedRow16(profile, hLoad, hStore, e, fIn *vector16, segments int, best *vector16, gaps *[2]vector16)
+
+// stripedSmithWaterman16 computes the score like stripedSmithWaterman32,
+// with SSE2 instructions on saturated 16-bit scores. It gives up, returning
+// false, as soon as a score may no longer fit in 16 bits, which happens on
+// long and similar sequences.
+func stripedSmithWaterman16(ctx context.Context, reference, query []byte, cfg config) (cell, bool, error) {
+	segments := (len(query) + lanes - 1) / lanes
+	var profiles [256][]vector16
+	var limits [256]int // best scores past which a row of the residue may overflow
+
+	hLoad := make([]vector16, segments)
+	hStore := make([]vector16, segments)
+	e := make([]vector16, segments)
+	fIn := make([]vector16, segments)
+	for k := range e {
+		e[k] = gaps16[1]
+	}
+
+	var end cell
+	endRow := make([]vector16, segments)
+
+	for i, residue := range reference {
+		if err := ctx.Err(); err != nil {
+			return cell{}, false, err
+		}
+
+		profile := profiles[residue]
+		if profile == nil {
+			var maxScore int
+			profile, maxScore = queryProfile16(residue, query, segments, cfg)
+			profiles[residue] = profile
+			limits[residue] = math.MaxInt16 - maxScore
+		}
+		if end.score > limits[residue] {
+			return cell{}, false, nil
+		}
+
+		hLoad, hStore = hStore, hLoad
+		var best vector16
+		stripedRow16(&profile[0], &hLoad[0], &hStore[0], &e[0], &fIn[0], segments, &best, &gaps16)
+		score := 0
+		for _, lane := range best {
+			if int(lane) > score {
+				score = int(lane)
+			}
+		}
+		if score >= end.score {
+			end.score, end.i = score, i+1
+			copy(endRow, hStore)
+		}
+	}
+
+	if end.score == 0 {
+		return cell{}, true, nil
+	}
+	for j := len(query) - 1; j >= 0; j-- {
+		if int(endRow[j%segments][j/segments]) == end.score {
+			end.j = j + 1
+			break
+		}
+	}
+	return end, true, nil
+}
+
+// queryProfile16 is queryProfile on 16-bit scores, also returning the
+// highest score of the profile. Scores below the int16 range are clamped,
+// which changes nothing as they can never be part of an alignment.
+func queryProfile16(residue byte, query []byte, segments int, cfg config) ([]vector16, int) {
+	profile := make([]vector16, segments)
+	maxScore := math.MinInt16
+	for k := range profile {
+		for l := range lanes {
+			j := l*segments + k
+			if j >= len(query) {
+				profile[k][l] = math.MinInt16
+				continue
+			}
+
+			score := cfg.score(residue, query[j])
+			if score > maxScore {
+				maxScore = score
+			}
+			switch {
+			case score < math.MinInt16:
+				score = math.MinInt16
+			case score > math.MaxInt16:
+				score = math.MaxInt16
+			}
+			profile[k][l] = int16(score)
+		}
+	}
+	return profile, maxScore
+}
+
+func broadcast16(score int16) vector16 {
+	var v vector16
+	for l := range v {
+		v[l] = score
+	}
+	return v
+}
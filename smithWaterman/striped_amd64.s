@@ -0,0 +1,99 @@
+//go:build !purego
+
+#include "textflag.h"
+
+// func stripedRow16(profile, hLoad, hStore, e, fIn *vector16, segments int, best *vector16, gaps *[2]vector16)
+//
+// Registers: X0 score, X1 horizontal gap, X2 zero, X3 best, X4 gap opening,
+// X5 gap extension, X6 and X7 temporaries, X8 padding score in lane 0.
+// Segments are addressed by their byte offset in DI, up to BX.
+TEXT ·stripedRow16(SB), NOSPLIT, $0-64
+	MOVQ profile+0(FP), SI
+	MOVQ hLoad+8(FP), R8
+	MOVQ hStore+16(FP), R9
+	MOVQ e+24(FP), R10
+	MOVQ fIn+32(FP), R11
+	MOVQ segments+40(FP), BX
+	MOVQ best+48(FP), DX
+	MOVQ gaps+56(FP), AX
+
+	MOVOU 0(AX), X4
+	MOVOU 16(AX), X5
+	MOVOU (DX), X3
+	PXOR  X2, X2
+	MOVQ  $0x8000, AX
+	MOVQ  AX, X8
+	SHLQ  $4, BX
+
+	// Padding score in every lane
+	PCMPEQW X1, X1
+	PSLLW   $15, X1
+
+	// The diagonal of the first segment is the last segment of the row
+	// above, one lane up, the score left of the query being 0
+	MOVOU -16(R8)(BX*1), X0
+	PSLLO $2, X0
+	XORQ  DI, DI
+
+row:
+	MOVOU  X1, (R11)(DI*1)
+	MOVOU  (SI)(DI*1), X6
+	PADDSW X6, X0
+	MOVOU  (R10)(DI*1), X6
+	PMAXSW X6, X0
+	PMAXSW X1, X0
+	PMAXSW X2, X0
+	PMAXSW X0, X3
+	MOVOU  X0, (R9)(DI*1)
+
+	// Gaps opened from the score or extended, vertical then horizontal
+	PADDSW X4, X0
+	PADDSW X5, X6
+	PMAXSW X0, X6
+	MOVOU  X6, (R10)(DI*1)
+	PADDSW X5, X1
+	PMAXSW X0, X1
+
+	MOVOU (R8)(DI*1), X0
+	ADDQ  $16, DI
+	CMPQ  DI, BX
+	JB    row
+
+	// Carry the horizontal gaps of each lane over to the next one, for as
+	// long as they score more than the gaps already counted
+	PSLLO $2, X1
+	POR   X8, X1
+	XORQ  DI, DI
+
+lazy:
+	MOVOU    (R11)(DI*1), X6
+	MOVOU    X1, X7
+	PCMPGTW  X6, X7
+	PMOVMSKB X7, AX
+	TESTL    AX, AX
+	JZ       done
+
+	PMAXSW X1, X6
+	MOVOU  X6, (R11)(DI*1)
+	MOVOU  (R9)(DI*1), X6
+	PMAXSW X1, X6
+	MOVOU  X6, (R9)(DI*1)
+	PMAXSW X6, X3
+	MOVOU  X1, X7
+	PADDSW X4, X7
+	MOVOU  (R10)(DI*1), X6
+	PMAXSW X7, X6
+	MOVOU  X6, (R10)(DI*1)
+	PADDSW X5, X1
+
+	ADDQ $16, DI
+	CMPQ DI, BX
+	JB   lazy
+	PSLLO $2, X1
+	POR   X8, X1
+	XORQ  DI, DI
+	JMP   lazy
+
+done:
+	MOVOU X3, (DX)
+	RET
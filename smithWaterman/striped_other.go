@@ -0,0 +1,11 @@
+//go:build !amd64 || purego
+
+package smith_waterman
+
+import "context"
+
+// stripedSmithWaterman16 has no vector kernel to run here, leaving every
+// alignment to stripedSmithWaterman32
+func stripedSmithWaterman16(ctx context.Context, reference, query []byte, cfg config) (cell, bool, error) {
+	return cell{}, false, nil
+}
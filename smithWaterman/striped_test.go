@@ -0,0 +1,138 @@
+package smith_waterman
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	substitution_matrix "github.com/vsdbmv2/worker-go/substitutionMatrix"
+)
+
+// referenceScore scores s1 and s2 with the original kernel, over strings
+func referenceScore(t testing.TB, s1, s2 string, cfg config) int {
+	reference := strings.Split(s1, "")
+	query := strings.Split(s2, "")
+	score, err := computeSmithWaterman(context.Background(), reference, query, ge, go_, mt, mst, cfg.matrix,
+		make([]int, len(query)+1), make([]int, len(query)+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return score
+}
+
+func randomSequence(rng *rand.Rand, alphabet string, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[rng.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+// mutate returns s with substitutions and insertions or deletions of up to
+// maxGap residues, so that the best alignments have long gaps
+func mutate(rng *rand.Rand, alphabet, s string, maxGap int) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch r := rng.Intn(40); {
+		case r == 0:
+			b.WriteString(randomSequence(rng, alphabet, 1+rng.Intn(maxGap)))
+			b.WriteByte(s[i])
+		case r == 1:
+			i += rng.Intn(maxGap)
+		case r < 5:
+			b.WriteByte(alphabet[rng.Intn(len(alphabet))])
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func TestStripedSmithWaterman(t *testing.T) {
+	blosum62, err := substitution_matrix.Lookup("BLOSUM62")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		alphabet string
+		cfg      config
+	}{
+		{name: "nucleotides", alphabet: "ACGT"},
+		{name: "proteins", alphabet: "ARNDCQEGHILKMFPSTWYV", cfg: config{matrix: blosum62}},
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	lengths := []int{1, 2, lanes - 1, lanes, lanes + 1, 3 * lanes, 50, 101, 300}
+	for _, tt := range tests {
+		for _, n := range lengths {
+			for _, m := range lengths {
+				s1 := randomSequence(rng, tt.alphabet, n)
+				pairs := [][2]string{
+					{s1, randomSequence(rng, tt.alphabet, m)},
+					{s1, mutate(rng, tt.alphabet, s1, 1+m/4)},
+				}
+				for _, pair := range pairs {
+					if pair[1] == "" {
+						continue
+					}
+					want, err := bestCell(context.Background(), []byte(pair[0]), []byte(pair[1]), tt.cfg)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if score := referenceScore(t, pair[0], pair[1], tt.cfg); want.score != score {
+						t.Fatalf("%s: bestCell(%q, %q) scores %d, want %d", tt.name, pair[0], pair[1], want.score, score)
+					}
+					got, err := stripedSmithWaterman(context.Background(), []byte(pair[0]), []byte(pair[1]), tt.cfg)
+					if err != nil || got != want {
+						t.Errorf("%s: stripedSmithWaterman(%q, %q) = %+v, %v, want %+v", tt.name, pair[0], pair[1], got, err, want)
+					}
+					got, err = stripedSmithWaterman32(context.Background(), []byte(pair[0]), []byte(pair[1]), tt.cfg)
+					if err != nil || got != want {
+						t.Errorf("%s: stripedSmithWaterman32(%q, %q) = %+v, %v, want %+v", tt.name, pair[0], pair[1], got, err, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+// Scores past the int16 range leave the 16-bit kernel for the 32-bit one
+func TestStripedSmithWatermanLongScores(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	s := randomSequence(rng, "ACGT", 7000)
+	want := cell{score: mt * len(s), i: len(s), j: len(s)}
+	got, err := stripedSmithWaterman(context.Background(), []byte(s), []byte(s), config{})
+	if err != nil || got != want {
+		t.Errorf("stripedSmithWaterman(s, s) = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func BenchmarkSmithWaterman(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	s1 := randomSequence(rng, "ACGT", 1000)
+	s2 := mutate(rng, "ACGT", s1, 10)
+	cfg := config{}
+
+	b.Run("reference", func(b *testing.B) {
+		for range b.N {
+			referenceScore(b, s1, s2, cfg)
+		}
+	})
+	b.Run("striped32", func(b *testing.B) {
+		for range b.N {
+			if _, err := stripedSmithWaterman32(context.Background(), []byte(s1), []byte(s2), cfg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("striped", func(b *testing.B) {
+		for range b.N {
+			if _, err := stripedSmithWaterman(context.Background(), []byte(s1), []byte(s2), cfg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
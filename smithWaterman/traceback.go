@@ -0,0 +1,279 @@
+package smith_waterman
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+)
+
+// Traceback of a cell, packed in one byte: the low bits tell where its best
+// score comes from, the flags whether the gaps ending there extend a gap of
+// the previous cell or open a new one.
+const (
+	fromNone     byte = iota // the local alignment starts here
+	fromDiagonal             // match or mismatch
+	fromUp                   // gap in the query
+	fromLeft                 // gap in the reference
+
+	directionMask byte = 3
+	upExtended    byte = 1 << 2
+	leftExtended  byte = 1 << 3
+)
+
+// Alignment describes the best local alignment of two sequences. Coordinates
+// are 0-based and end exclusive.
+type Alignment struct {
+	Score            int
+	ReferenceStart   int
+	ReferenceEnd     int
+	QueryStart       int
+	QueryEnd         int
+	AlignedReference string
+	AlignedQuery     string
+	Identity         float64 // percentage of columns with identical residues
+	Similarity       float64 // percentage of columns with a positive score
+	Gaps             int     // number of columns with a gap
+}
+
+// SmithWatermanAlignment performs local sequence alignment like SmithWaterman,
+// and traces the best alignment back to report where it lies on both
+// sequences and what it looks like.
+func SmithWatermanAlignment(referenceSequence, querySequence string, opts ...Option) (Alignment, error) {
+	return SmithWatermanAlignmentContext(context.Background(), referenceSequence, querySequence, opts...)
+}
+
+// SmithWatermanAlignmentContext is SmithWatermanAlignment, checking ctx
+// before every row of the matrix and giving up with its error once it is done
+func SmithWatermanAlignmentContext(ctx context.Context, referenceSequence, querySequence string, opts ...Option) (Alignment, error) {
+	if len(referenceSequence) == 0 {
+		return Alignment{}, errors.New("empty reference sequence")
+	}
+	if len(querySequence) == 0 {
+		return Alignment{}, errors.New("empty query sequence")
+	}
+
+	cfg := newConfig(opts)
+	reference := []byte(strings.ToUpper(referenceSequence))
+	query := []byte(strings.ToUpper(querySequence))
+
+	// The striped kernel scores the whole matrix in linear memory, only the
+	// part holding the best alignment being traced back
+	end, err := stripedSmithWaterman(ctx, reference, query, cfg)
+	if err != nil {
+		return Alignment{}, err
+	}
+	if end.score == 0 {
+		return Alignment{}, nil
+	}
+	startI, startJ, err := alignmentStart(ctx, reference, query, end, cfg)
+	if err != nil {
+		return Alignment{}, err
+	}
+
+	reference, query = reference[startI:end.i], query[startJ:end.j]
+	directions, err := fillDirections(ctx, reference, query, cfg)
+	if err != nil {
+		return Alignment{}, err
+	}
+	alignment := traceBack(reference, query, directions, len(reference), len(query), cfg)
+	alignment.Score = end.score
+	alignment.ReferenceStart += startI
+	alignment.ReferenceEnd += startI
+	alignment.QueryStart += startJ
+	alignment.QueryEnd += startJ
+	return alignment, nil
+}
+
+// cell is a cell of the matrix and its score, rows going along the reference
+// and columns along the query
+type cell struct {
+	score, i, j int
+}
+
+// alignmentStart returns the smallest reference and query positions at which
+// an alignment ending at end with its score may start, so that the one
+// traced back lies between them and end. It aligns the sequences backwards
+// from end, every alignment having to take the residues of end first, and
+// stops once no cell left can reach the score of end.
+func alignmentStart(ctx context.Context, reference, query []byte, end cell, cfg config) (int, int, error) {
+	const minScore = math.MinInt32 / 2
+	cols := end.j + 1
+	gain := cfg.maxScore()
+
+	// Cells (i, j) score alignments ending with the residues i and j places
+	// before end, only cell (0, 0) having none
+	scores := make([]int, cols)
+	upScores := make([]int, cols)
+	for j := range scores {
+		scores[j], upScores[j] = minScore, minScore
+	}
+	scores[0] = 0
+	startI, startJ := end.i, end.j
+
+	for i := 1; i <= end.i; i++ {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+
+		residue := reference[end.i-i]
+		leftScore := minScore
+		lastDiag := scores[0]
+		scores[0] = minScore
+		rowBest := minScore
+
+		for j := 1; j < cols; j++ {
+			similarity := lastDiag + cfg.score(residue, query[end.j-j])
+			upScores[j] = max([]int{upScores[j] + ge, scores[j] + go_})
+			leftScore = max([]int{leftScore + ge, scores[j-1] + go_})
+			lastDiag = scores[j]
+			scores[j] = max([]int{similarity, upScores[j], leftScore})
+			if scores[j] == end.score {
+				startI = min(startI, end.i-i)
+				startJ = min(startJ, end.j-j)
+			}
+			rowBest = max([]int{rowBest, scores[j], upScores[j]})
+		}
+
+		// Each row further gains at most one residue pair
+		if rowBest+gain*min(end.i-i, end.j) < end.score {
+			break
+		}
+	}
+	return startI, startJ, nil
+}
+
+// fillDirections returns the traceback of every cell of the matrix, with the
+// same recurrences as computeSmithWaterman
+func fillDirections(ctx context.Context, reference, query []byte, cfg config) ([]byte, error) {
+	rows, cols := len(reference)+1, len(query)+1
+	directions := make([]byte, rows*cols)
+	scores := make([]int, cols)
+	upScores := make([]int, cols)
+
+	for i := 1; i < rows; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		leftScore := math.MinInt32
+		lastDiag := 0
+
+		for j := 1; j < cols; j++ {
+			var direction byte
+			similarity := lastDiag + cfg.score(reference[i-1], query[j-1])
+
+			if upScores[j]+ge > scores[j]+go_ {
+				upScores[j] += ge
+				direction |= upExtended
+			} else {
+				upScores[j] = scores[j] + go_
+			}
+
+			if leftScore+ge > scores[j-1]+go_ {
+				leftScore += ge
+				direction |= leftExtended
+			} else {
+				leftScore = scores[j-1] + go_
+			}
+
+			lastDiag = scores[j]
+			score := max([]int{similarity, upScores[j], leftScore, 0})
+			switch score {
+			case 0:
+				direction |= fromNone
+			case similarity:
+				direction |= fromDiagonal
+			case upScores[j]:
+				direction |= fromUp
+			default:
+				direction |= fromLeft
+			}
+
+			scores[j] = score
+			directions[i*cols+j] = direction
+		}
+	}
+	return directions, nil
+}
+
+func traceBack(reference, query, directions []byte, endI, endJ int, cfg config) Alignment {
+	cols := len(query) + 1
+	var alignedReference, alignedQuery []byte
+	var identical, similar, gaps int
+
+	// state is fromUp or fromLeft while inside a gap, fromDiagonal otherwise
+	i, j := endI, endJ
+	state := fromDiagonal
+trace:
+	for i > 0 && j > 0 {
+		direction := directions[i*cols+j]
+
+		if state == fromDiagonal {
+			switch direction & directionMask {
+			case fromDiagonal:
+				a, b := reference[i-1], query[j-1]
+				alignedReference = append(alignedReference, a)
+				alignedQuery = append(alignedQuery, b)
+				if a == b {
+					identical++
+				}
+				if cfg.score(a, b) > 0 {
+					similar++
+				}
+				i--
+				j--
+				continue
+			case fromNone:
+				break trace
+			default:
+				state = direction & directionMask
+			}
+		}
+
+		gaps++
+		if state == fromUp {
+			alignedReference = append(alignedReference, reference[i-1])
+			alignedQuery = append(alignedQuery, '-')
+			if direction&upExtended == 0 {
+				state = fromDiagonal
+			}
+			i--
+		} else {
+			alignedReference = append(alignedReference, '-')
+			alignedQuery = append(alignedQuery, query[j-1])
+			if direction&leftExtended == 0 {
+				state = fromDiagonal
+			}
+			j--
+		}
+	}
+
+	reverse(alignedReference)
+	reverse(alignedQuery)
+
+	alignment := Alignment{
+		ReferenceStart:   i,
+		ReferenceEnd:     endI,
+		QueryStart:       j,
+		QueryEnd:         endJ,
+		AlignedReference: string(alignedReference),
+		AlignedQuery:     string(alignedQuery),
+		Gaps:             gaps,
+	}
+	if length := len(alignedReference); length > 0 {
+		alignment.Identity = percentage(identical, length)
+		alignment.Similarity = percentage(similar, length)
+	}
+	return alignment
+}
+
+func percentage(count, total int) float64 {
+	return math.Round(float64(count)*10000/float64(total)) / 100
+}
+
+func reverse(s []byte) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
@@ -0,0 +1,177 @@
+package smith_waterman
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	substitution_matrix "github.com/vsdbmv2/worker-go/substitutionMatrix"
+)
+
+func TestSmithWatermanAlignment(t *testing.T) {
+	tests := []struct {
+		seq1 string
+		seq2 string
+		want Alignment
+	}{
+		{
+			seq1: "ttgccagccccctgatgggcgacactccaa",
+			seq2: "ccctgatgggggcgacactc",
+			want: Alignment{
+				Score:            79,
+				ReferenceStart:   9,
+				ReferenceEnd:     27,
+				QueryStart:       0,
+				QueryEnd:         20,
+				AlignedReference: "CCCTGAT--GGGCGACACTC",
+				AlignedQuery:     "CCCTGATGGGGGCGACACTC",
+				Identity:         90,
+				Similarity:       90,
+				Gaps:             2,
+			},
+		},
+		{
+			seq1: "ACGTACGTTTTT",
+			seq2: "GGACGTACGAGG",
+			want: Alignment{
+				Score:            35,
+				ReferenceStart:   0,
+				ReferenceEnd:     7,
+				QueryStart:       2,
+				QueryEnd:         9,
+				AlignedReference: "ACGTACG",
+				AlignedQuery:     "ACGTACG",
+				Identity:         100,
+				Similarity:       100,
+				Gaps:             0,
+			},
+		},
+		{
+			seq1: "AAAA",
+			seq2: "CCCC",
+			want: Alignment{},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := SmithWatermanAlignment(tt.seq1, tt.seq2)
+		if err != nil {
+			t.Errorf("SmithWatermanAlignment(%v, %v) unexpected error %v", tt.seq1, tt.seq2, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("SmithWatermanAlignment(%v, %v) = %+v, want %+v", tt.seq1, tt.seq2, got, tt.want)
+		}
+		score, _ := SmithWaterman(tt.seq1, tt.seq2)
+		if got.Score != score {
+			t.Errorf("SmithWatermanAlignment(%v, %v) score = %v, SmithWaterman = %v", tt.seq1, tt.seq2, got.Score, score)
+		}
+	}
+}
+
+// bestCell returns the cell of the best score like stripedSmithWaterman,
+// with the recurrences of computeSmithWaterman
+func bestCell(ctx context.Context, reference, query []byte, cfg config) (cell, error) {
+	cols := len(query) + 1
+
+	scores := make([]int, cols)
+	upScores := make([]int, cols)
+	var best cell
+
+	for i := 1; i <= len(reference); i++ {
+		if err := ctx.Err(); err != nil {
+			return cell{}, err
+		}
+
+		leftScore := math.MinInt32
+		lastDiag := 0
+
+		for j := 1; j < cols; j++ {
+			similarity := lastDiag + cfg.score(reference[i-1], query[j-1])
+			upScores[j] = max([]int{upScores[j] + ge, scores[j] + go_})
+			leftScore = max([]int{leftScore + ge, scores[j-1] + go_})
+			lastDiag = scores[j]
+			scores[j] = max([]int{similarity, upScores[j], leftScore, 0})
+			if best.score <= scores[j] {
+				best = cell{score: scores[j], i: i, j: j}
+			}
+		}
+	}
+	if best.score == 0 {
+		return cell{}, nil
+	}
+	return best, nil
+}
+
+// fullAlignment traces the best alignment back over the whole matrix
+func fullAlignment(t *testing.T, reference, query []byte, cfg config) Alignment {
+	end, err := bestCell(context.Background(), reference, query, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if end.score == 0 {
+		return Alignment{}
+	}
+	directions, err := fillDirections(context.Background(), reference, query, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alignment := traceBack(reference, query, directions, end.i, end.j, cfg)
+	alignment.Score = end.score
+	return alignment
+}
+
+// Tracing back only the part of the matrix holding the alignment finds the
+// one of the whole matrix, including across gaps and repeats
+func TestSmithWatermanAlignmentSubMatrix(t *testing.T) {
+	blosum62, err := substitution_matrix.Lookup("BLOSUM62")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		alphabet string
+		opts     []Option
+	}{
+		{name: "nucleotides", alphabet: "ACGT"},
+		{name: "repeats", alphabet: "AC"},
+		{name: "proteins", alphabet: "ARNDCQEGHILKMFPSTWYV", opts: []Option{WithMatrix(blosum62)}},
+	}
+
+	rng := rand.New(rand.NewSource(7))
+	for _, tt := range tests {
+		for k := 0; k < 100; k++ {
+			s1 := randomSequence(rng, tt.alphabet, 1+rng.Intn(300))
+			s2 := mutate(rng, tt.alphabet, s1[rng.Intn(len(s1)):], 1+rng.Intn(20))
+			if k%5 == 0 || s2 == "" {
+				s2 = randomSequence(rng, tt.alphabet, 1+rng.Intn(100))
+			}
+
+			want := fullAlignment(t, []byte(s1), []byte(s2), newConfig(tt.opts))
+			got, err := SmithWatermanAlignment(s1, s2, tt.opts...)
+			if err != nil || got != want {
+				t.Errorf("%s: SmithWatermanAlignment(%v, %v) = %+v, %v, want %+v", tt.name, s1, s2, got, err, want)
+			}
+			if got.Score > 0 && (strings.ReplaceAll(got.AlignedReference, "-", "") != s1[got.ReferenceStart:got.ReferenceEnd] ||
+				strings.ReplaceAll(got.AlignedQuery, "-", "") != s2[got.QueryStart:got.QueryEnd]) {
+				t.Errorf("%s: SmithWatermanAlignment(%v, %v) aligned strings do not match its coordinates", tt.name, s1, s2)
+			}
+		}
+	}
+}
+
+func TestSmithWatermanContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := SmithWatermanAlignmentContext(ctx, "ACGTACGT", "CGTA"); !errors.Is(err, context.Canceled) {
+		t.Errorf("SmithWatermanAlignmentContext() error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := SmithWatermanContext(ctx, "ACGTACGT", "CGTA"); !errors.Is(err, context.Canceled) {
+		t.Errorf("SmithWatermanContext() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,235 @@
+package substitution_matrix
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed matrices
+var builtin embed.FS
+
+// Matrix scores every pair of residues of an alphabet
+type Matrix struct {
+	Name     string
+	Alphabet string
+
+	size   int
+	scores []int
+	index  [256]int
+}
+
+// Residues looked up, in order, for characters outside the matrix alphabet
+const fallbackResidues = "XN*"
+
+// Score returns the substitution score of residues a and b. Lookups are case
+// insensitive, U is scored as T in nucleotide matrices and any other residue
+// outside the alphabet is scored as X, N or * when the matrix has one of them.
+func (m *Matrix) Score(a, b byte) int {
+	return m.scores[m.index[a]*m.size+m.index[b]]
+}
+
+// Parse reads a matrix in NCBI format: '#' comment lines, a header line with
+// the alphabet and one row per residue starting with that residue.
+func Parse(name string, r io.Reader) (*Matrix, error) {
+	var alphabet []byte
+	var rows [][]int
+	seen := make(map[byte]bool)
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if alphabet == nil {
+			for _, field := range fields {
+				if len(field) != 1 {
+					return nil, fmt.Errorf("%s:%d: invalid residue %q in header", name, line, field)
+				}
+				residue := upper(field[0])
+				if seen[residue] {
+					return nil, fmt.Errorf("%s:%d: duplicate residue %q in header", name, line, field)
+				}
+				seen[residue] = true
+				alphabet = append(alphabet, residue)
+			}
+			continue
+		}
+
+		if len(rows) == len(alphabet) {
+			return nil, fmt.Errorf("%s:%d: more rows than residues in header", name, line)
+		}
+		if len(fields) != len(alphabet)+1 {
+			return nil, fmt.Errorf("%s:%d: expected %d scores, got %d", name, line, len(alphabet), len(fields)-1)
+		}
+		if len(fields[0]) != 1 || upper(fields[0][0]) != alphabet[len(rows)] {
+			return nil, fmt.Errorf("%s:%d: expected row %q, got %q", name, line, alphabet[len(rows)], fields[0])
+		}
+
+		row := make([]int, len(alphabet))
+		for i, field := range fields[1:] {
+			score, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid score %q", name, line, field)
+			}
+			row[i] = score
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(alphabet) == 0 {
+		return nil, fmt.Errorf("%s: missing header", name)
+	}
+	if len(rows) != len(alphabet) {
+		return nil, fmt.Errorf("%s: expected %d rows, got %d", name, len(alphabet), len(rows))
+	}
+
+	return newMatrix(name, alphabet, rows), nil
+}
+
+func newMatrix(name string, alphabet []byte, rows [][]int) *Matrix {
+	m := &Matrix{
+		Name:     name,
+		Alphabet: string(alphabet),
+		size:     len(alphabet),
+	}
+
+	position := make(map[byte]int, len(alphabet))
+	for i, residue := range alphabet {
+		position[residue] = i
+	}
+
+	// Residues outside the alphabet share the first fallback row, or an extra
+	// row with the lowest score of the matrix when there is none.
+	unknown, ok := -1, false
+	for i := 0; i < len(fallbackResidues) && !ok; i++ {
+		unknown, ok = position[fallbackResidues[i]]
+	}
+	if !ok {
+		lowest := rows[0][0]
+		for _, row := range rows {
+			for _, score := range row {
+				lowest = min(lowest, score)
+			}
+		}
+		unknown = m.size
+		m.size++
+		extra := make([]int, m.size)
+		for i := range rows {
+			rows[i] = append(rows[i], lowest)
+			extra[i] = lowest
+		}
+		extra[unknown] = lowest
+		rows = append(rows, extra)
+	}
+
+	m.scores = make([]int, 0, m.size*m.size)
+	for _, row := range rows {
+		m.scores = append(m.scores, row...)
+	}
+
+	for c := 0; c < 256; c++ {
+		residue := upper(byte(c))
+		if residue == 'U' {
+			if _, ok := position['U']; !ok {
+				residue = 'T'
+			}
+		}
+		if i, ok := position[residue]; ok {
+			m.index[c] = i
+		} else {
+			m.index[c] = unknown
+		}
+	}
+
+	return m
+}
+
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+// Load reads an NCBI-format matrix file, named after the file
+func Load(path string) (*Matrix, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Parse(filepath.Base(path), file)
+}
+
+var (
+	mu       sync.RWMutex
+	matrices = make(map[string]*Matrix)
+)
+
+func init() {
+	entries, err := builtin.ReadDir("matrices")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		file, err := builtin.Open("matrices/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		m, err := Parse(entry.Name(), file)
+		file.Close()
+		if err != nil {
+			panic(err)
+		}
+		Register(m)
+	}
+}
+
+// Register makes m available to Lookup under its name, replacing any matrix
+// already registered with the same name.
+func Register(m *Matrix) {
+	mu.Lock()
+	defer mu.Unlock()
+	matrices[strings.ToUpper(m.Name)] = m
+}
+
+// Lookup returns the built-in or registered matrix with the given name, case
+// insensitive, such as BLOSUM62 or NUC.4.4.
+func Lookup(name string) (*Matrix, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	m, ok := matrices[strings.ToUpper(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown substitution matrix %q", name)
+	}
+	return m, nil
+}
+
+// Names lists the names of every available matrix
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(matrices))
+	for _, m := range matrices {
+		names = append(names, m.Name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,75 @@
+package substitution_matrix
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinMatrices(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  byte
+		score int
+	}{
+		{name: "BLOSUM62", a: 'W', b: 'W', score: 11},
+		{name: "blosum62", a: 'a', b: 'r', score: -1},
+		{name: "BLOSUM62", a: 'U', b: 'A', score: 0},
+		{name: "BLOSUM45", a: 'C', b: 'C', score: 12},
+		{name: "BLOSUM80", a: 'D', b: 'W', score: -6},
+		{name: "PAM250", a: 'W', b: 'W', score: 17},
+		{name: "NUC.4.4", a: 'A', b: 'R', score: 1},
+		{name: "NUC.4.4", a: 'u', b: 'T', score: 5},
+		{name: "NUC.4.4", a: 'X', b: 'A', score: -2},
+	}
+
+	for _, tt := range tests {
+		m, err := Lookup(tt.name)
+		if err != nil {
+			t.Fatalf("Lookup(%v) error = %v", tt.name, err)
+		}
+		if got := m.Score(tt.a, tt.b); got != tt.score {
+			t.Errorf("%v.Score(%c, %c) = %v, want %v", tt.name, tt.a, tt.b, got, tt.score)
+		}
+	}
+
+	for _, name := range Names() {
+		m, _ := Lookup(name)
+		for i := 0; i < len(m.Alphabet); i++ {
+			for j := 0; j < len(m.Alphabet); j++ {
+				a, b := m.Alphabet[i], m.Alphabet[j]
+				if m.Score(a, b) != m.Score(b, a) {
+					t.Errorf("%v is not symmetric for %c and %c", name, a, b)
+				}
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{input: "# comment\n  A  C\nA 1 -1\nC -1 1\n"},
+		{input: "  A  C\nA 1 -1\n", wantErr: true},
+		{input: "  A  C\nA 1 -1\nG -1 1\n", wantErr: true},
+		{input: "  A  C\nA 1 x\nC -1 1\n", wantErr: true},
+		{input: "  A  C\nA 1\nC -1 1\n", wantErr: true},
+		{input: "# only comments\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		m, err := Parse("test", strings.NewReader(tt.input))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if m.Score('A', 'A') != 1 || m.Score('a', 'c') != -1 || m.Score('G', 'A') != -1 {
+			t.Errorf("Parse(%q) scored A/A=%v A/C=%v G/A=%v", tt.input,
+				m.Score('A', 'A'), m.Score('a', 'c'), m.Score('G', 'A'))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package translation
+
+import (
+	"fmt"
+	"sort"
+)
+
+// GeneticCode is an NCBI genetic code, translating codons into amino acids
+type GeneticCode struct {
+	ID   int
+	Name string
+
+	// Amino acid of every codon of ambiguous nucleotides, indexed by the
+	// nucleotide sets of its three bases, X when they code different ones
+	codons [16 * 16 * 16]byte
+}
+
+// Amino acids of the codons of each code, NCBI-style: the first base of the
+// codon varies slowest, in TCAG order.
+var codes = []struct {
+	id         int
+	name       string
+	aminoAcids string
+}{
+	{1, "Standard", "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{2, "Vertebrate Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG"},
+	{3, "Yeast Mitochondrial", "FFLLSSSSYY**CCWWTTTTPPPPHHQQRRRRIIMMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{4, "Mold, Protozoan, and Coelenterate Mitochondrial and Mycoplasma/Spiroplasma", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{5, "Invertebrate Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSSSVVVVAAAADDEEGGGG"},
+	{6, "Ciliate, Dasycladacean and Hexamita Nuclear", "FFLLSSSSYYQQCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{9, "Echinoderm and Flatworm Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG"},
+	{10, "Euplotid Nuclear", "FFLLSSSSYY**CCCWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{11, "Bacterial, Archaeal and Plant Plastid", "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{12, "Alternative Yeast Nuclear", "FFLLSSSSYY**CC*WLLLSPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{13, "Ascidian Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSGGVVVVAAAADDEEGGGG"},
+	{14, "Alternative Flatworm Mitochondrial", "FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG"},
+	{16, "Chlorophycean Mitochondrial", "FFLLSSSSYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{21, "Trematode Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNNKSSSSVVVVAAAADDEEGGGG"},
+	{22, "Scenedesmus obliquus Mitochondrial", "FFLLSS*SYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{23, "Thraustochytrium Mitochondrial", "FF*LSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{24, "Rhabdopleuridae Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG"},
+	{25, "Candidate Division SR1 and Gracilibacteria", "FFLLSSSSYY**CCGWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{26, "Pachysolen tannophilus Nuclear", "FFLLSSSSYY**CC*WLLLAPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{29, "Mesodinium Nuclear", "FFLLSSSSYYYYCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{30, "Peritrich Nuclear", "FFLLSSSSYYEECC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"},
+	{33, "Cephalodiscidae Mitochondrial", "FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG"},
+}
+
+var registry = func() map[int]*GeneticCode {
+	registry := make(map[int]*GeneticCode, len(codes))
+	for _, code := range codes {
+		registry[code.id] = newGeneticCode(code.id, code.name, code.aminoAcids)
+	}
+	return registry
+}()
+
+// Standard is the standard genetic code, NCBI table 1
+var Standard = registry[1]
+
+// Lookup returns the genetic code with the given NCBI table ID
+func Lookup(id int) (*GeneticCode, error) {
+	if code, ok := registry[id]; ok {
+		return code, nil
+	}
+	return nil, fmt.Errorf("unknown genetic code %d", id)
+}
+
+// IDs returns the IDs of the available genetic codes, in increasing order
+func IDs() []int {
+	ids := make([]int, 0, len(registry))
+	for id := range registry {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// newGeneticCode expands the 64 codons of an NCBI table to every codon of
+// ambiguous nucleotides
+func newGeneticCode(id int, name, aminoAcids string) *GeneticCode {
+	code := &GeneticCode{ID: id, Name: name}
+	for first := range 16 {
+		for second := range 16 {
+			for third := range 16 {
+				aminoAcid := byte(0)
+				for _, a := range bases(first) {
+					for _, b := range bases(second) {
+						for _, c := range bases(third) {
+							found := aminoAcids[a*16+b*4+c]
+							if aminoAcid == 0 {
+								aminoAcid = found
+							} else if aminoAcid != found {
+								aminoAcid = 'X'
+							}
+						}
+					}
+				}
+				if aminoAcid == 0 {
+					aminoAcid = 'X'
+				}
+				code.codons[first*256+second*16+third] = aminoAcid
+			}
+		}
+	}
+	return code
+}
+
+// bases returns the TCAG indices of the nucleotides in a set
+func bases(set int) []int {
+	var indices []int
+	for i, nucleotide := range []int{t, c, a, g} {
+		if set&nucleotide != 0 {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
@@ -0,0 +1,52 @@
+package translation
+
+import "testing"
+
+func TestGeneticCodes(t *testing.T) {
+	tests := []struct {
+		id    int
+		codon string
+		want  string
+	}{
+		{id: 1, codon: "ATGTGATAAAGA", want: "M**R"},
+		{id: 2, codon: "ATATGAAGAAGG", want: "MW**"},
+		{id: 3, codon: "CTGATA", want: "TM"},
+		{id: 5, codon: "AGAAGG", want: "SS"},
+		{id: 6, codon: "TAATAGTGA", want: "QQ*"},
+		{id: 11, codon: "TTGTGA", want: "L*"},
+		{id: 12, codon: "CTG", want: "S"},
+		{id: 13, codon: "AGAAGG", want: "GG"},
+		{id: 14, codon: "TAAAAA", want: "YN"},
+		{id: 25, codon: "TGA", want: "G"},
+	}
+
+	for _, tt := range tests {
+		code, err := Lookup(tt.id)
+		if err != nil {
+			t.Errorf("Lookup(%v) unexpected error %v", tt.id, err)
+			continue
+		}
+		if got := code.Translate(tt.codon); got != tt.want {
+			t.Errorf("code %v Translate(%v) = %v, want %v", tt.id, tt.codon, got, tt.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if _, err := Lookup(7); err == nil {
+		t.Errorf("Lookup(7) error = nil, want unknown genetic code")
+	}
+	if code, err := Lookup(1); err != nil || code != Standard {
+		t.Errorf("Lookup(1) = %v, %v, want the standard code", code, err)
+	}
+
+	// Every table codes 64 codons with amino acids or stops only
+	for _, id := range IDs() {
+		code, _ := Lookup(id)
+		for _, codon := range []string{"TTT", "CCC", "AAA", "GGG", "TGA", "TAG", "TAA", "ATA", "AGG", "CTG"} {
+			if aminoAcid := code.Translate(codon); aminoAcid == "X" {
+				t.Errorf("code %v Translate(%v) = X", id, codon)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package translation
+
+// Nucleotide sets, as bit masks
+const (
+	a = 1 << iota
+	c
+	g
+	t
+)
+
+// nucleotides maps IUPAC nucleotide codes, in either case, to their set of
+// nucleotides. Anything else is the empty set, which translates to X.
+var nucleotides = func() [256]int {
+	var sets [256]int
+	for code, set := range map[byte]int{
+		'A': a, 'C': c, 'G': g, 'T': t, 'U': t,
+		'R': a | g, 'Y': c | t, 'S': c | g, 'W': a | t, 'K': g | t, 'M': a | c,
+		'B': c | g | t, 'D': a | g | t, 'H': a | c | t, 'V': a | c | g, 'N': a | c | g | t,
+	} {
+		sets[code] = set
+		sets[code-'A'+'a'] = set
+	}
+	return sets
+}()
+
+// complements maps IUPAC nucleotide codes to the code of their complement,
+// keeping the case and leaving anything else as is
+var complements = func() [256]byte {
+	var complements [256]byte
+	for i := range complements {
+		complements[i] = byte(i)
+	}
+	for _, pair := range []string{"AT", "CG", "RY", "KM", "BV", "DH"} {
+		for _, p := range []string{pair, pair[1:] + pair[:1]} {
+			complements[p[0]] = p[1]
+			complements[p[0]-'A'+'a'] = p[1] - 'A' + 'a'
+		}
+	}
+	complements['U'], complements['u'] = 'A', 'a'
+	return complements
+}()
+
+// Translate translates a nucleotide sequence from its first base, leaving out
+// a trailing partial codon. Codons with ambiguity codes translate to their
+// amino acid when all the codons they stand for code it, and to X otherwise.
+func (code *GeneticCode) Translate(sequence string) string {
+	peptide := make([]byte, len(sequence)/3)
+	for i := range peptide {
+		first := nucleotides[sequence[3*i]]
+		second := nucleotides[sequence[3*i+1]]
+		third := nucleotides[sequence[3*i+2]]
+		peptide[i] = code.codons[first*256+second*16+third]
+	}
+	return string(peptide)
+}
+
+// ReverseComplement returns the reverse complement of a nucleotide sequence
+func ReverseComplement(sequence string) string {
+	reversed := make([]byte, len(sequence))
+	for i := range len(sequence) {
+		reversed[len(sequence)-1-i] = complements[sequence[i]]
+	}
+	return string(reversed)
+}
+
+// Frame is the translation of a sequence in one of its six reading frames
+type Frame struct {
+	Number  int // 1 to 3 on the sequence, -1 to -3 on its reverse complement
+	Peptide string
+}
+
+// Frames translates a nucleotide sequence in its six reading frames, starting
+// at its first, second and third bases, then at those of its reverse
+// complement.
+func (code *GeneticCode) Frames(sequence string) []Frame {
+	reverse := ReverseComplement(sequence)
+	frames := make([]Frame, 0, 6)
+	for _, strand := range []struct {
+		sign     int
+		sequence string
+	}{{1, sequence}, {-1, reverse}} {
+		for offset := 0; offset < 3; offset++ {
+			peptide := ""
+			if offset < len(strand.sequence) {
+				peptide = code.Translate(strand.sequence[offset:])
+			}
+			frames = append(frames, Frame{Number: strand.sign * (offset + 1), Peptide: peptide})
+		}
+	}
+	return frames
+}
+
+// NucleotideRange returns where the amino acids from start to end, end
+// excluded, of the frame come from in a sequence of the given length. The
+// range is 0-based, end excluded, on the sequence itself for every frame.
+func (f Frame) NucleotideRange(start, end, sequenceLength int) (int, int) {
+	if f.Number > 0 {
+		offset := f.Number - 1
+		return offset + 3*start, offset + 3*end
+	}
+	offset := -f.Number - 1
+	return sequenceLength - offset - 3*end, sequenceLength - offset - 3*start
+}
@@ -0,0 +1,76 @@
+package translation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		sequence string
+		want     string
+	}{
+		{sequence: "ATGGCCATTGTAATGGGCCGCTGAAAGGGTGCCCGATAG", want: "MAIVMGR*KGAR*"},
+		{sequence: "atggcc", want: "MA"},
+		{sequence: "AUGGCC", want: "MA"},
+		{sequence: "ATGGC", want: "M"},
+		// Ambiguity codes translate when all their codons agree
+		{sequence: "GCNCTNTAR", want: "AL*"},
+		{sequence: "ATNTAN---", want: "XXX"},
+	}
+
+	for _, tt := range tests {
+		if got := Standard.Translate(tt.sequence); got != tt.want {
+			t.Errorf("Translate(%v) = %v, want %v", tt.sequence, got, tt.want)
+		}
+	}
+}
+
+func TestReverseComplement(t *testing.T) {
+	tests := []struct {
+		sequence string
+		want     string
+	}{
+		{sequence: "ATGC", want: "GCAT"},
+		{sequence: "acgtn", want: "nacgt"},
+		{sequence: "RYKMBVDHSW-", want: "-WSDHBVKMRY"},
+	}
+
+	for _, tt := range tests {
+		if got := ReverseComplement(tt.sequence); got != tt.want {
+			t.Errorf("ReverseComplement(%v) = %v, want %v", tt.sequence, got, tt.want)
+		}
+	}
+}
+
+func TestFrames(t *testing.T) {
+	sequence := "ATGGCCATTGTAA"
+	want := []Frame{
+		{Number: 1, Peptide: "MAIV"},
+		{Number: 2, Peptide: "WPL*"},
+		{Number: 3, Peptide: "GHC"},
+		{Number: -1, Peptide: "LQWP"},
+		{Number: -2, Peptide: "YNGH"},
+		{Number: -3, Peptide: "TMA"},
+	}
+
+	got := Standard.Frames(sequence)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Frames(%v) = %v, want %v", sequence, got, want)
+	}
+
+	// The nucleotides of every amino acid translate back to it
+	for _, frame := range got {
+		for i := range len(frame.Peptide) {
+			start, end := frame.NucleotideRange(i, i+1, len(sequence))
+			codon := sequence[start:end]
+			if frame.Number < 0 {
+				codon = ReverseComplement(codon)
+			}
+			if aminoAcid := Standard.Translate(codon); aminoAcid != frame.Peptide[i:i+1] {
+				t.Errorf("frame %v NucleotideRange(%v) = %v, %v, translating to %v, want %v",
+					frame.Number, i, start, end, aminoAcid, frame.Peptide[i:i+1])
+			}
+		}
+	}
+}